@@ -4,10 +4,9 @@ go 1.15
 
 require (
 	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
-	github.com/alicebob/miniredis v2.5.0+incompatible
+	github.com/alicebob/miniredis/v2 v2.17.0
 	github.com/datawire/ambassador v1.14.4
 	github.com/go-redis/redis/v8 v8.8.2
-	github.com/gomodule/redigo v1.8.4 // indirect
 	github.com/gorilla/mux v1.8.0
 	github.com/joho/godotenv v1.5.1
 	github.com/onsi/ginkgo v1.16.2
@@ -17,6 +16,7 @@ require (
 	github.com/sirupsen/logrus v1.9.0
 	github.com/yuin/gopher-lua v0.0.0-20200816102855-ee81675732da // indirect
 	google.golang.org/grpc v1.37.0
+	google.golang.org/protobuf v1.28.1
 )
 
 replace (
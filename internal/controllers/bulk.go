@@ -0,0 +1,164 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : bulk.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	"github.com/gorilla/mux"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Media type of a stream of JSON values, one per line
+const ndjsonType = "application/x-ndjson"
+
+// ------------------------ GLOBAL -------------------- //
+
+// ------------------------ HTTP REST -------------------- //
+
+// ImportConfigs : Create or update configs in bulk, all or nothing
+func ImportConfigs(w http.ResponseWriter, r *http.Request) {
+	log.Info("Importing configs")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain := domainParam(params)
+
+	// Decode body, a JSON array or a stream of JSON values
+	var items []models.ConfigItem
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), ndjsonType) {
+		items, err = decodeItems(r.Body)
+	} else {
+		err = helper.DecodeJSON(r.Body, &items)
+	}
+
+	if err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Import configs
+	report, err := services.ImportConfigs(domain, items)
+
+	var validationErr *helper.ValidationError
+	if errors.As(err, &validationErr) {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// ExportConfigs : Every config, in the format ImportConfigs reads
+func ExportConfigs(w http.ResponseWriter, r *http.Request) {
+	log.Info("Exporting configs")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, query := domainParam(params), r.URL.Query()
+
+	// Parse query
+	var counters bool
+	if value := query.Get("counters"); len(value) > 0 {
+		var err error
+		if counters, err = strconv.ParseBool(value); err != nil {
+			helper.GetValidationError(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "counters", Message: "must be true or false"},
+			}}, w)
+			return
+		}
+	}
+
+	// Export configs
+	items, err := services.ExportConfigs(domain, counters)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Response, a stream of JSON values when asked for
+	if strings.Contains(r.Header.Get("Accept"), ndjsonType) {
+		w.Header().Set("Content-Type", ndjsonType)
+
+		encoder := json.NewEncoder(w)
+		for _, item := range items {
+			_ = encoder.Encode(item)
+		}
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(items)
+}
+
+// decodeItems : Decode a stream of configs, one JSON value per line,
+// as strictly as a single one
+func decodeItems(body io.Reader) ([]models.ConfigItem, error) {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	var items []models.ConfigItem
+	for {
+		var item models.ConfigItem
+		err := decoder.Decode(&item)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if len(items) <= 0 {
+		return nil, errors.New("body is empty")
+	}
+
+	return items, nil
+}
+
+// ------------------------ HTTP REST -------------------- //
@@ -0,0 +1,87 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : cost.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+)
+
+// ------------------------ HTTP REST -------------------- //
+
+// GetCosts : CRUD
+func GetCosts(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning costs")
+
+	// Get costs
+	costs, err := services.GetCosts()
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(costs)
+}
+
+// SetCosts : CRUD
+func SetCosts(w http.ResponseWriter, r *http.Request) {
+	log.Info("Setting costs")
+
+	// Decode body
+	var costs models.Costs
+	if err := helper.DecodeJSON(r.Body, &costs); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Validate costs
+	if err := services.ValidateCosts(costs); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Set costs
+	err := services.SetCosts(costs)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(costs)
+}
+
+// ------------------------ HTTP REST -------------------- //
@@ -0,0 +1,153 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : counter.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	"github.com/gorilla/mux"
+)
+
+// ------------------------ HTTP REST -------------------- //
+
+// ResetCounters : Reset the current window of limits of a uid
+func ResetCounters(w http.ResponseWriter, r *http.Request) {
+	log.Info("Resetting counters")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid := domainParam(params), params["uid"]
+
+	// Decode body
+	var reset models.CounterReset
+	if err := helper.DecodeJSON(r.Body, &reset); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Reset counters
+	err := services.ResetCounters(domain, uid, reset)
+	writeUsage(domain, uid, err, w)
+}
+
+// CreditCounters : Grant hits to a uid, taken before its quotas
+func CreditCounters(w http.ResponseWriter, r *http.Request) {
+	log.Info("Crediting counters")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid := domainParam(params), params["uid"]
+
+	// Decode body
+	var credit models.CounterCredit
+	if err := helper.DecodeJSON(r.Body, &credit); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Credit counters
+	err := services.CreditCounters(domain, uid, credit)
+	writeUsage(domain, uid, err, w)
+}
+
+// SetCounter : Set the hits used in the current window of a limit of a uid
+func SetCounter(w http.ResponseWriter, r *http.Request) {
+	log.Info("Setting counter")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid, limit := domainParam(params), params["uid"], params["limit"]
+
+	// Decode body
+	var value models.CounterValue
+	if err := helper.DecodeJSON(r.Body, &value); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Set counter
+	err := services.SetCounter(domain, uid, limit, value)
+	writeUsage(domain, uid, err, w)
+}
+
+// GetAudit : Operations on the counters of a uid
+func GetAudit(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning audit trail")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid := domainParam(params), params["uid"]
+
+	// Get audit trail
+	entries, err := services.GetAudit(domain, uid)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+// writeUsage : Respond to an operation on counters
+// with the usage of the uid once done
+func writeUsage(domain string, uid string, err error, w http.ResponseWriter) {
+	var validationErr *helper.ValidationError
+	switch {
+	case err == services.ErrNoConfig, err == services.ErrUnknownLimit:
+		helper.GetNotFoundError(w)
+		return
+	case errors.As(err, &validationErr):
+		helper.GetValidationError(err, w)
+		return
+	case err != nil:
+		helper.GetError(err, w)
+		return
+	}
+
+	// Get usage
+	usage, err := services.GetUsage(domain, uid)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(usage)
+}
+
+// ------------------------ HTTP REST -------------------- //
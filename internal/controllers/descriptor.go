@@ -0,0 +1,95 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : descriptor.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	"github.com/gorilla/mux"
+)
+
+// ------------------------ HTTP REST -------------------- //
+
+// GetDescriptors : CRUD
+func GetDescriptors(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning descriptors")
+
+	// Get params
+	domain := domainParam(mux.Vars(r))
+
+	// Get descriptors
+	descriptors, err := services.GetDescriptors(domain)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(descriptors)
+}
+
+// SetDescriptors : CRUD
+func SetDescriptors(w http.ResponseWriter, r *http.Request) {
+	log.Info("Setting descriptors")
+
+	// Get params
+	domain := domainParam(mux.Vars(r))
+
+	// Decode body
+	var descriptors []models.Descriptor
+	if err := helper.DecodeJSON(r.Body, &descriptors); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Validate descriptors
+	if err := services.ValidateDescriptors(descriptors); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Set descriptors
+	err := services.SetDescriptors(domain, descriptors)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(descriptors)
+}
+
+// ------------------------ HTTP REST -------------------- //
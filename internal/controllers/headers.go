@@ -0,0 +1,117 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : headers.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/services"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Naming of the rate limit headers
+// X-RateLimit-* (default)
+// RateLimit-* (IETF draft)
+// Both of them
+// None
+const (
+	legacyHeaders = "x-ratelimit"
+	draftHeaders  = "ratelimit"
+	bothHeaders   = "both"
+	noHeaders     = "none"
+)
+
+const retryAfterHeader = "Retry-After"
+
+// ------------------------ GLOBAL -------------------- //
+
+// header : Response header added by the proxy
+type header struct {
+	key   string
+	value string
+}
+
+// rateLimitHeaders : Headers telling the client about its most restrictive
+// limit, the first exhausted one or the one with the fewest hits left
+func rateLimitHeaders(results []services.Result) []header {
+	current, remaining := -1, 0
+	for index, result := range results {
+		usage, ok := result.CurrentUsage()
+		if !ok {
+			continue
+		}
+
+		if !result.OK {
+			current = index
+			break
+		}
+
+		if current < 0 || usage.Remaining < remaining {
+			current, remaining = index, usage.Remaining
+		}
+	}
+
+	if current < 0 {
+		return nil
+	}
+
+	limit, _, _ := results[current].Current()
+	usage, _ := results[current].CurrentUsage()
+	reset := strconv.FormatInt(seconds(usage.Reset), 10)
+	values := []string{strconv.Itoa(limit.Number), strconv.Itoa(usage.Remaining), reset}
+
+	// Names of the limit, remaining and reset headers
+	var prefixes []string
+	switch strings.ToLower(helper.GetConfiguration().RateLimitHeaders) {
+	case noHeaders:
+		return nil
+	case draftHeaders:
+		prefixes = []string{"RateLimit-"}
+	case bothHeaders:
+		prefixes = []string{"X-RateLimit-", "RateLimit-"}
+	default: // Default is X-RateLimit-*
+		prefixes = []string{"X-RateLimit-"}
+	}
+
+	headers := make([]header, 0, 3*len(prefixes)+1)
+	for _, prefix := range prefixes {
+		for index, name := range []string{"Limit", "Remaining", "Reset"} {
+			headers = append(headers, header{key: prefix + name, value: values[index]})
+		}
+	}
+
+	// Retry once the exhausted limit resets
+	if !results[current].OK && usage.Reset > 0 {
+		headers = append(headers, header{key: retryAfterHeader, value: reset})
+	}
+
+	return headers
+}
+
+// seconds : Duration in whole seconds, rounded up
+func seconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
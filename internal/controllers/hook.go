@@ -0,0 +1,108 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : hook.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const signatureHeader = "X-Signature"
+const replayedHeader = "Idempotent-Replayed"
+
+// Largest event body read
+const maxEventBody = 1 << 20
+
+// ------------------------ GLOBAL -------------------- //
+
+// ------------------------ HTTP REST -------------------- //
+
+// PolicyHook : Uids changed or revoked by the policy service, signed
+// with the shared secret and applied once per event id
+func PolicyHook(w http.ResponseWriter, r *http.Request) {
+	log.Info("Receiving policy event")
+
+	// Read body, as signed
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxEventBody))
+	if err != nil {
+		helper.GetBadRequestError(w)
+		return
+	}
+
+	// Verify signature
+	if !services.VerifySignature(body, r.Header.Get(signatureHeader)) {
+		helper.GetUnauthorizedError(w)
+		return
+	}
+
+	// Decode body
+	var event models.PolicyEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Validate event
+	if err := services.ValidatePolicyEvent(event); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Ignore replays
+	claimed, err := services.ClaimPolicyEvent(event.ID, body)
+	switch {
+	case err == services.ErrEventConflict:
+		helper.GetConflictError(err, w)
+		return
+	case err != nil:
+		helper.GetError(err, w)
+		return
+	}
+
+	if claimed {
+		// Apply event, or let it be sent again
+		if err := services.ApplyPolicyEvent(event); err != nil {
+			_ = services.ReleasePolicyEvent(event.ID)
+			helper.GetError(err, w)
+			return
+		}
+	} else {
+		w.Header().Set(replayedHeader, "true")
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode("OK")
+}
+
+// ------------------------ HTTP REST -------------------- //
@@ -0,0 +1,145 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : plan.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	"github.com/gorilla/mux"
+)
+
+// ------------------------ HTTP REST -------------------- //
+
+// GetPlans : CRUD
+func GetPlans(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning plans")
+
+	// Get plans
+	plans, err := services.GetPlans()
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(plans)
+}
+
+// GetPlan : CRUD
+func GetPlan(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning plan")
+
+	// Get params
+	var params = mux.Vars(r)
+	name := params["plan"]
+
+	// Get plan
+	plan, err := services.GetPlan(name)
+
+	if err != nil {
+		helper.GetNotFoundError(w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(plan)
+}
+
+// CreateOrUpdatePlan : CRUD
+func CreateOrUpdatePlan(w http.ResponseWriter, r *http.Request) {
+	log.Info("Creating or Updating plan")
+
+	// Get params
+	var params = mux.Vars(r)
+	name := params["plan"]
+
+	// Decode body
+	var plan models.Config
+	if err := helper.DecodeJSON(r.Body, &plan); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Validate plan
+	if err := services.ValidatePlan(name, plan); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Create plan
+	err := services.CreateOrUpdatePlan(name, plan)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(plan)
+}
+
+// DeletePlan : CRUD
+func DeletePlan(w http.ResponseWriter, r *http.Request) {
+	log.Info("Deleting plan")
+
+	// Get params
+	var params = mux.Vars(r)
+	name := params["plan"]
+
+	// Delete plan
+	err := services.DeletePlan(name)
+
+	if errors.Is(err, services.ErrPlanInUse) {
+		helper.GetConflictError(err, w)
+		return
+	}
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode("OK")
+}
+
+// ------------------------ HTTP REST -------------------- //
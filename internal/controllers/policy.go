@@ -0,0 +1,117 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : policy.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	"github.com/gorilla/mux"
+)
+
+// ------------------------ HTTP REST -------------------- //
+
+// GetPolicy : CRUD
+func GetPolicy(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning policy")
+
+	// Get params
+	domain := domainParam(mux.Vars(r))
+
+	// Get policy
+	policy, err := services.GetPolicy(domain)
+
+	if err != nil {
+		helper.GetNotFoundError(w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(policy)
+}
+
+// SetPolicy : CRUD
+func SetPolicy(w http.ResponseWriter, r *http.Request) {
+	log.Info("Setting policy")
+
+	// Get params
+	domain := domainParam(mux.Vars(r))
+
+	// Decode body
+	var policy models.Policy
+	if err := helper.DecodeJSON(r.Body, &policy); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	// Validate policy
+	if err := services.ValidatePolicy(policy); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Set policy
+	err := services.SetPolicy(domain, policy)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(policy)
+}
+
+// DeletePolicy : CRUD
+func DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	log.Info("Deleting policy")
+
+	// Get params
+	domain := domainParam(mux.Vars(r))
+
+	// Delete policy
+	err := services.DeletePolicy(domain)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode("OK")
+}
+
+// ------------------------ HTTP REST -------------------- //
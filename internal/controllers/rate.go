@@ -23,18 +23,26 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bit-broker/rate-service/internal/helper"
 	"github.com/bit-broker/rate-service/internal/models"
 	"github.com/bit-broker/rate-service/internal/services"
 	"github.com/bit-broker/rate-service/pkg/log"
 
+	core "github.com/datawire/ambassador/pkg/api/envoy/api/v2/core"
 	ratelimit "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v2"
 
 	"github.com/gorilla/mux"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // ------------------------ HTTP REST -------------------- //
@@ -45,19 +53,16 @@ func GetConfig(w http.ResponseWriter, r *http.Request) {
 
 	// Get params
 	var params = mux.Vars(r)
-	uid := params["uid"]
+	domain, uid := domainParam(params), params["uid"]
 
-	// Get config
-	config, err := services.GetConfig(uid)
+	// Get config, along with its origin
+	config, err := services.GetStoredConfig(domain, uid)
 
 	if err != nil {
 		helper.GetNotFoundError(w)
 		return
 	}
 
-	// Remove log
-	config.Log = nil
-
 	// Set header.
 	w.Header().Set("Content-Type", "application/json")
 
@@ -71,14 +76,101 @@ func CreateOrUpdateConfig(w http.ResponseWriter, r *http.Request) {
 
 	// Get params
 	var params = mux.Vars(r)
-	uid := params["uid"]
+	domain, uid := domainParam(params), params["uid"]
+
+	// Validate uid
+	if !services.ValidUID(uid) {
+		helper.GetInvalidUIDError(uid, w)
+		return
+	}
 
-	// Decode body
-	var config models.Config
-	_ = json.NewDecoder(r.Body).Decode(&config)
+	// Decode body, the origin returned along with a config is ignored
+	var stored models.StoredConfig
+	if err := helper.DecodeJSON(r.Body, &stored); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+	config := stored.Config
+
+	// Validate config
+	if err := services.ValidateConfig(config); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
 
 	// Create config
-	err := services.CreateOrUpdateConfig(uid, config)
+	err := services.CreateOrUpdateDomainConfig(domain, uid, config)
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(config)
+}
+
+// PatchConfig : CRUD, the config is merge patched (RFC 7396)
+// and checked as a whole, its counters being left untouched
+func PatchConfig(w http.ResponseWriter, r *http.Request) {
+	log.Info("Patching config")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid := domainParam(params), params["uid"]
+
+	// Validate uid
+	if !services.ValidUID(uid) {
+		helper.GetInvalidUIDError(uid, w)
+		return
+	}
+
+	// Decode body, a merge patch of the config
+	var patch json.RawMessage
+	if err := helper.DecodeJSON(r.Body, &patch); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	if !bytes.HasPrefix(patch, []byte("{")) {
+		helper.GetDecodeError(errors.New("patch must be a JSON object"), w)
+		return
+	}
+
+	// Get config
+	stored, err := services.GetStoredConfig(domain, uid)
+
+	if err != nil {
+		helper.GetNotFoundError(w)
+		return
+	}
+
+	// Patch config, the origin being ignored as on update
+	document, _ := json.Marshal(stored.Config)
+	merged, err := helper.MergePatch(document, patch)
+	if err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+
+	var patched models.StoredConfig
+	if err := helper.DecodeJSON(bytes.NewReader(merged), &patched); err != nil {
+		helper.GetDecodeError(err, w)
+		return
+	}
+	config := patched.Config
+
+	// Validate config
+	if err := services.ValidateConfig(config); err != nil {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	// Update config
+	err = services.CreateOrUpdateDomainConfig(domain, uid, config)
 
 	if err != nil {
 		helper.GetError(err, w)
@@ -98,10 +190,10 @@ func DeleteConfig(w http.ResponseWriter, r *http.Request) {
 
 	// Get params
 	var params = mux.Vars(r)
-	uid := params["uid"]
+	domain, uid := domainParam(params), params["uid"]
 
 	// Delete config
-	err := services.DeleteConfig(uid)
+	err := services.DeleteDomainConfig(domain, uid)
 
 	if err != nil {
 		helper.GetError(err, w)
@@ -115,6 +207,109 @@ func DeleteConfig(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode("OK")
 }
 
+// ListConfigs : Configs matching the filters of the query, a page at a time
+func ListConfigs(w http.ResponseWriter, r *http.Request) {
+	log.Info("Listing configs")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, query := domainParam(params), r.URL.Query()
+
+	// Parse query
+	var violations []helper.Violation
+	filter := models.ConfigFilter{
+		Plan:     query.Get("plan"),
+		Interval: models.IntervalType(query.Get("interval_type")),
+	}
+
+	if value := query.Get("enabled"); len(value) > 0 {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			violations = append(violations, helper.Violation{Field: "enabled", Message: "must be true or false"})
+		}
+		filter.Enabled = &enabled
+	}
+
+	var size int
+	if value := query.Get("limit"); len(value) > 0 {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size <= 0 {
+			violations = append(violations, helper.Violation{Field: "limit", Message: "must be a positive integer"})
+		}
+	}
+
+	var total bool
+	if value := query.Get("total"); len(value) > 0 {
+		var err error
+		if total, err = strconv.ParseBool(value); err != nil {
+			violations = append(violations, helper.Violation{Field: "total", Message: "must be true or false"})
+		}
+	}
+
+	if len(violations) > 0 {
+		helper.GetValidationError(&helper.ValidationError{Violations: violations}, w)
+		return
+	}
+
+	// List configs
+	page, err := services.ListConfigs(domain, filter, query.Get("cursor"), size, total)
+
+	var validationErr *helper.ValidationError
+	if errors.As(err, &validationErr) {
+		helper.GetValidationError(err, w)
+		return
+	}
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// GetUsage : Hits used and left of every limit of a uid
+func GetUsage(w http.ResponseWriter, r *http.Request) {
+	log.Info("Returning usage")
+
+	// Get params
+	var params = mux.Vars(r)
+	domain, uid := domainParam(params), params["uid"]
+
+	// Get usage, without charging
+	usage, err := services.GetUsage(domain, uid)
+
+	if err == services.ErrNoConfig {
+		helper.GetNotFoundError(w)
+		return
+	}
+
+	if err != nil {
+		helper.GetError(err, w)
+		return
+	}
+
+	// Set header.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Response
+	_ = json.NewEncoder(w).Encode(usage)
+}
+
+// domainParam : Domain of a route, the default
+// one for the routes without domain
+func domainParam(params map[string]string) string {
+	if domain := params["domain"]; len(domain) > 0 {
+		return domain
+	}
+
+	return services.DefaultDomain
+}
+
 // ------------------------ HTTP REST -------------------- //
 
 // ------------------------ GRPC ------------------------- //
@@ -127,38 +322,199 @@ type RatelimitService struct {
 func (r RatelimitService) ShouldRateLimit(ctx context.Context, request *ratelimit.RateLimitRequest) (*ratelimit.RateLimitResponse, error) {
 	log.Info("Received request", request)
 
-	// Get uid
-	var uid string
+	// Get entries
+	descriptors := make([][]models.Entry, 0, len(request.Descriptors))
 	for _, descriptor := range request.Descriptors {
+		entries := make([]models.Entry, 0, len(descriptor.Entries))
 		for _, entry := range descriptor.Entries {
-			if entry.Key == "uid" {
-				uid = entry.Value
-			}
+			entries = append(entries, models.Entry{Key: entry.Key, Value: entry.Value})
 		}
+		descriptors = append(descriptors, entries)
 	}
 
-	// If uid not present
-	if len(uid) <= 0 {
+	response, _, err := rateLimit(request.Domain, descriptors, request.HitsAddend)
+
+	return response, err
+}
+
+// rateLimit : Answer to a request of a domain, along with the result of every
+// descriptor, shared by both versions of the interface.
+// Returns an error only when the policy fails closed with a gRPC error.
+func rateLimit(domain string, descriptors [][]models.Entry, hitsAddend uint32) (*ratelimit.RateLimitResponse, []services.Result, error) {
+	// If neither uid nor limits present
+	results, known, err := checkDescriptors(domain, descriptors, hitsAddend)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !known {
 		log.Debug("Over Limit")
 		return &ratelimit.RateLimitResponse{
 			OverallCode: ratelimit.RateLimitResponse_OVER_LIMIT,
-		}, nil
+		}, nil, nil
 	}
 
-	// Check config
-	ok, _ := services.Check(uid)
+	// Descriptor statuses
+	code := ratelimit.RateLimitResponse_OK
+	statuses := make([]*ratelimit.RateLimitResponse_DescriptorStatus, len(results))
+	for index, result := range results {
+		statuses[index] = descriptorStatus(result)
 
-	if !ok {
-		log.Debug("Over Limit")
-		return &ratelimit.RateLimitResponse{
-			OverallCode: ratelimit.RateLimitResponse_OVER_LIMIT,
-		}, nil
+		if statuses[index].Code == ratelimit.RateLimitResponse_OVER_LIMIT {
+			code = ratelimit.RateLimitResponse_OVER_LIMIT
+		}
 	}
 
-	log.Debug("OK")
+	log.Debug(code)
 	return &ratelimit.RateLimitResponse{
-		OverallCode: ratelimit.RateLimitResponse_OK,
-	}, nil
+		OverallCode: code,
+		Statuses:    statuses,
+		Headers:     headerValues(rateLimitHeaders(results)),
+	}, results, nil
+}
+
+// headerValues : Headers for the proxy
+func headerValues(headers []header) []*core.HeaderValue {
+	values := make([]*core.HeaderValue, 0, len(headers))
+	for _, h := range headers {
+		values = append(values, &core.HeaderValue{Key: h.key, Value: h.value})
+	}
+
+	return values
+}
+
+// checkDescriptors : Check and charge every descriptor of a request of a domain,
+// the limits being charged only when the whole request is within them, and tell
+// whether the request is to be answered per descriptor, the ones without uid nor
+// limits aren't unless the policy of the domain says otherwise.
+// Returns an error only when the policy fails closed with a gRPC error.
+func checkDescriptors(domain string, descriptors [][]models.Entry, hitsAddend uint32) ([]services.Result, bool, error) {
+	// Get uid and route, the route cost is for the whole request
+	var hasUID bool
+	var route string
+	for _, entries := range descriptors {
+		for _, entry := range entries {
+			switch entry.Key {
+			case "uid":
+				hasUID = true
+			case "route":
+				route = entry.Value
+			}
+		}
+	}
+
+	// Get cost, the hits addend first then the route cost
+	hits := int(hitsAddend)
+	if hits <= 0 {
+		hits, _ = services.GetRouteCost(route)
+	}
+
+	policy := services.FailurePolicy(domain)
+
+	// Check every descriptor, all or nothing
+	known := hasUID
+	results, errs := services.EvaluateDescriptors(domain, descriptors, hits)
+	for index, entries := range descriptors {
+		if errs[index] != nil {
+			result, err := failedCheck(domain, entries, hits, policy, errs[index])
+			if err != nil {
+				return nil, false, err
+			}
+			results[index] = result
+			known = true
+		}
+		known = known || len(results[index].Limits) > 0
+	}
+
+	if known {
+		return results, true, nil
+	}
+
+	// Neither uid nor limits present
+	switch policy.MissingUID {
+	case models.FailOpenPolicy:
+		return results, true, nil
+	case models.DefaultLimitPolicy:
+		result, err := services.EvaluateAnonymous(domain, hits, policy)
+		if err != nil {
+			if result, err = failedCheck(domain, nil, hits, policy, err); err != nil {
+				return nil, false, err
+			}
+		}
+
+		// The anonymous limit applies to every descriptor
+		for index := range results {
+			results[index] = result
+		}
+		return results, true, nil
+	default: // Default is fail closed
+		return results, false, nil
+	}
+}
+
+// failedCheck : Result of a descriptor which couldn't be checked following the
+// policy, or the gRPC error to return when failing closed with an error. Only
+// the failures of Redis or of the policy service follow the policy, the uids
+// without config are rejected.
+func failedCheck(domain string, entries []models.Entry, hits int, policy models.Policy, err error) (services.Result, error) {
+	if !errors.Is(err, services.ErrUnavailable) {
+		log.Debug("Check rejected ", err)
+		return services.Result{Exhausted: -1}, nil
+	}
+
+	log.Error("Check failed ", err)
+
+	switch policy.Error {
+	case models.FailOpenPolicy, models.DefaultLimitPolicy:
+	default: // Default is fail closed
+		if policy.GRPCError {
+			return services.Result{}, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+
+	return services.EvaluateFailure(domain, entries, hits, policy), nil
+}
+
+// descriptorStatus : Status of a descriptor, with the
+// exhausted limit or the first one if any
+func descriptorStatus(result services.Result) *ratelimit.RateLimitResponse_DescriptorStatus {
+	status := &ratelimit.RateLimitResponse_DescriptorStatus{
+		Code: ratelimit.RateLimitResponse_OK,
+	}
+	if !result.OK {
+		status.Code = ratelimit.RateLimitResponse_OVER_LIMIT
+	}
+
+	if limit, window, ok := result.Current(); ok && limit.Number > 0 {
+		status.CurrentLimit = &ratelimit.RateLimitResponse_RateLimit{
+			Name:            limit.Name,
+			RequestsPerUnit: uint32(limit.Number),
+			Unit:            rateLimitUnit(window),
+		}
+	}
+
+	if usage, ok := result.CurrentUsage(); ok {
+		status.LimitRemaining = uint32(usage.Remaining)
+	}
+
+	return status
+}
+
+// rateLimitUnit : Envoy unit of a rate window, unknown
+// when the window isn't exactly one unit
+func rateLimitUnit(window time.Duration) ratelimit.RateLimitResponse_RateLimit_Unit {
+	switch window {
+	case time.Second:
+		return ratelimit.RateLimitResponse_RateLimit_SECOND
+	case time.Minute:
+		return ratelimit.RateLimitResponse_RateLimit_MINUTE
+	case time.Hour:
+		return ratelimit.RateLimitResponse_RateLimit_HOUR
+	case 24 * time.Hour:
+		return ratelimit.RateLimitResponse_RateLimit_DAY
+	default:
+		return ratelimit.RateLimitResponse_RateLimit_UNKNOWN
+	}
 }
 
 // ------------------------ GRPC ------------------------- //
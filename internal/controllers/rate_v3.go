@@ -0,0 +1,127 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : rate_v3.go
+ * Creation Date : 18-10-2026
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/log"
+
+	core "github.com/datawire/ambassador/pkg/api/envoy/config/core/v3"
+	ratelimitv2 "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v2"
+	ratelimit "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v3"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ------------------------ GRPC ------------------------- //
+
+// RatelimitServiceV3 : gRPC Rate Limit Interface, v3
+type RatelimitServiceV3 struct {
+}
+
+// ShouldRateLimit : gRPC Rate Limit Interface, v3
+func (r RatelimitServiceV3) ShouldRateLimit(ctx context.Context, request *ratelimit.RateLimitRequest) (*ratelimit.RateLimitResponse, error) {
+	log.Info("Received v3 request", request)
+
+	// Get entries
+	descriptors := make([][]models.Entry, 0, len(request.Descriptors))
+	for _, descriptor := range request.Descriptors {
+		entries := make([]models.Entry, 0, len(descriptor.Entries))
+		for _, entry := range descriptor.Entries {
+			entries = append(entries, models.Entry{Key: entry.Key, Value: entry.Value})
+		}
+		descriptors = append(descriptors, entries)
+	}
+
+	response, results, err := rateLimit(request.Domain, descriptors, request.HitsAddend)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseV3(response, results), nil
+}
+
+// responseV3 : Answer to a request in v3 from the one in v2, along with the
+// time until the limit of every descriptor resets and the exhausted limits
+func responseV3(response *ratelimitv2.RateLimitResponse, results []services.Result) *ratelimit.RateLimitResponse {
+	// Codes and units have the same values in both versions
+	answer := &ratelimit.RateLimitResponse{
+		OverallCode: ratelimit.RateLimitResponse_Code(response.OverallCode),
+	}
+
+	exhausted := make([]interface{}, 0)
+	for index, status := range response.Statuses {
+		statusV3 := &ratelimit.RateLimitResponse_DescriptorStatus{
+			Code:           ratelimit.RateLimitResponse_Code(status.Code),
+			LimitRemaining: status.LimitRemaining,
+		}
+
+		if limit := status.CurrentLimit; limit != nil {
+			statusV3.CurrentLimit = &ratelimit.RateLimitResponse_RateLimit{
+				Name:            limit.Name,
+				RequestsPerUnit: limit.RequestsPerUnit,
+				Unit:            ratelimit.RateLimitResponse_RateLimit_Unit(limit.Unit),
+			}
+		}
+
+		if usage, ok := results[index].CurrentUsage(); ok {
+			statusV3.DurationUntilReset = durationpb.New(usage.Reset)
+		}
+
+		if statusV3.Code == ratelimit.RateLimitResponse_OVER_LIMIT {
+			exhausted = append(exhausted, statusV3.GetCurrentLimit().GetName())
+		}
+
+		answer.Statuses = append(answer.Statuses, statusV3)
+	}
+
+	for _, h := range response.Headers {
+		answer.ResponseHeadersToAdd = append(answer.ResponseHeadersToAdd, &core.HeaderValue{Key: h.Key, Value: h.Value})
+	}
+	answer.DynamicMetadata = dynamicMetadata(exhausted)
+
+	return answer
+}
+
+// dynamicMetadata : Metadata emitted by Envoy for the
+// request, with the names of the exhausted limits
+func dynamicMetadata(exhausted []interface{}) *structpb.Struct {
+	if len(exhausted) <= 0 {
+		return nil
+	}
+
+	metadata, err := structpb.NewStruct(map[string]interface{}{
+		"exhausted_limits": exhausted,
+	})
+	if err != nil {
+		log.Error("Dynamic metadata failed ", err)
+		return nil
+	}
+
+	return metadata
+}
+
+// ------------------------ GRPC ------------------------- //
@@ -41,7 +41,21 @@ type Configuration struct {
 	PolicyServiceEndpoint      string
 	PolicyServiceAuthorization string
 	PolicyServiceTimeout       string
+	PolicyServiceRetries       string
+	PolicyServiceBackoff       string
+	PolicyServiceUnknownTTL    string
+	PolicyServiceCacheTTL      string
+	PolicyServiceStaleTTL      string
+	PolicyServiceHookSecret    string
 	MetricsEnabled             string
+	ConfigCacheTTL             string
+	RateLimitHeaders           string
+	MissingUIDPolicy           string
+	ErrorPolicy                string
+	DefaultLimit               string
+	DefaultLimitUnit           string
+	ErrorGRPCStatus            string
+	DefaultPlan                string
 }
 
 // Env : Type of env
@@ -72,7 +86,21 @@ func GetConfiguration() Configuration {
 		PolicyServiceEndpoint:      os.Getenv("POLICY_SERVICE_ENDPOINT"),
 		PolicyServiceAuthorization: os.Getenv("POLICY_SERVICE_AUTHORIZATION"),
 		PolicyServiceTimeout:       os.Getenv("POLICY_SERVICE_TIMEOUT"),
+		PolicyServiceRetries:       os.Getenv("POLICY_SERVICE_RETRIES"),
+		PolicyServiceBackoff:       os.Getenv("POLICY_SERVICE_BACKOFF"),
+		PolicyServiceUnknownTTL:    os.Getenv("POLICY_SERVICE_UNKNOWN_TTL"),
+		PolicyServiceCacheTTL:      os.Getenv("POLICY_SERVICE_CACHE_TTL"),
+		PolicyServiceStaleTTL:      os.Getenv("POLICY_SERVICE_STALE_TTL"),
+		PolicyServiceHookSecret:    os.Getenv("POLICY_SERVICE_HOOK_SECRET"),
 		MetricsEnabled:             os.Getenv("METRICS_ENABLED"),
+		ConfigCacheTTL:             os.Getenv("CONFIG_CACHE_TTL"),
+		RateLimitHeaders:           os.Getenv("RATE_LIMIT_HEADERS"),
+		MissingUIDPolicy:           os.Getenv("MISSING_UID_POLICY"),
+		ErrorPolicy:                os.Getenv("ERROR_POLICY"),
+		DefaultLimit:               os.Getenv("DEFAULT_LIMIT"),
+		DefaultLimitUnit:           os.Getenv("DEFAULT_LIMIT_UNIT"),
+		ErrorGRPCStatus:            os.Getenv("ERROR_GRPC_STATUS"),
+		DefaultPlan:                os.Getenv("DEFAULT_PLAN"),
 	}
 
 	return configuration
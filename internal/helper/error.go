@@ -24,53 +24,155 @@ package helper
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/bit-broker/rate-service/pkg/log"
 )
 
+// Error codes
+const (
+	InternalErrorCode    = "internal_error"
+	NotFoundCode         = "not_found"
+	BadRequestCode       = "bad_request"
+	InvalidJSONCode      = "invalid_json"
+	UnknownFieldCode     = "unknown_field"
+	InvalidUIDCode       = "invalid_uid"
+	ValidationFailedCode = "validation_failed"
+	UnauthorizedCode     = "unauthorized"
+	ConflictCode         = "conflict"
+)
+
 // ErrorResponse : This is error model.
 type ErrorResponse struct {
-	StatusCode   int    `json:"status"`
-	ErrorMessage string `json:"message"`
+	StatusCode   int         `json:"status"`
+	Code         string      `json:"code"`
+	ErrorMessage string      `json:"message"`
+	Violations   []Violation `json:"violations,omitempty"`
+}
+
+// Violation : This is an invalid field of a request.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError : This is every invalid field of a request.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error : Every violation, one per field
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+
+	return strings.Join(messages, "; ")
 }
 
 // GetError : This is helper function to prepare internal standard error.
 func GetError(err error, w http.ResponseWriter) {
 	log.Error(err.Error())
-	var response = ErrorResponse{
+	writeError(w, ErrorResponse{
 		ErrorMessage: err.Error(),
+		Code:         InternalErrorCode,
 		StatusCode:   http.StatusInternalServerError,
-	}
-
-	message, _ := json.Marshal(response)
-
-	w.WriteHeader(response.StatusCode)
-	_, _ = w.Write(message)
+	})
 }
 
 // GetNotFoundError : This is helper function to prepare not found error.
 func GetNotFoundError(w http.ResponseWriter) {
-	var response = ErrorResponse{
+	writeError(w, ErrorResponse{
 		ErrorMessage: "Not Found",
+		Code:         NotFoundCode,
 		StatusCode:   http.StatusNotFound,
-	}
-
-	message, _ := json.Marshal(response)
-
-	w.WriteHeader(response.StatusCode)
-	_, _ = w.Write(message)
+	})
 }
 
 // GetBadRequestError : This is helper function to prepare bad request error.
 func GetBadRequestError(w http.ResponseWriter) {
-	var response = ErrorResponse{
+	writeError(w, ErrorResponse{
 		ErrorMessage: "Bad request",
+		Code:         BadRequestCode,
+		StatusCode:   http.StatusBadRequest,
+	})
+}
+
+// GetDecodeError : This is helper function to prepare the error of a body
+// which isn't valid JSON, or which holds unknown fields.
+func GetDecodeError(err error, w http.ResponseWriter) {
+	var response = ErrorResponse{
+		ErrorMessage: err.Error(),
+		Code:         InvalidJSONCode,
+		StatusCode:   http.StatusBadRequest,
+	}
+
+	if field := unknownField(err); len(field) > 0 {
+		response.Code = UnknownFieldCode
+		response.Violations = []Violation{{Field: field, Message: "unknown field"}}
+	}
+
+	writeError(w, response)
+}
+
+// GetInvalidUIDError : This is helper function to prepare invalid uid error.
+func GetInvalidUIDError(uid string, w http.ResponseWriter) {
+	writeError(w, ErrorResponse{
+		ErrorMessage: "uid must be an integer",
+		Code:         InvalidUIDCode,
 		StatusCode:   http.StatusBadRequest,
+		Violations:   []Violation{{Field: "uid", Message: "must be an integer, got " + strconv.Quote(uid)}},
+	})
+}
+
+// GetValidationError : This is helper function to prepare invalid request error,
+// unprocessable with the list of invalid fields when known.
+func GetValidationError(err error, w http.ResponseWriter) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		writeError(w, ErrorResponse{
+			ErrorMessage: "Validation failed",
+			Code:         ValidationFailedCode,
+			StatusCode:   http.StatusUnprocessableEntity,
+			Violations:   validationErr.Violations,
+		})
+		return
 	}
 
+	writeError(w, ErrorResponse{
+		ErrorMessage: err.Error(),
+		Code:         BadRequestCode,
+		StatusCode:   http.StatusBadRequest,
+	})
+}
+
+// GetUnauthorizedError : This is helper function to prepare unauthorized error.
+func GetUnauthorizedError(w http.ResponseWriter) {
+	writeError(w, ErrorResponse{
+		ErrorMessage: "Unauthorized",
+		Code:         UnauthorizedCode,
+		StatusCode:   http.StatusUnauthorized,
+	})
+}
+
+// GetConflictError : This is helper function to prepare conflict error.
+func GetConflictError(err error, w http.ResponseWriter) {
+	writeError(w, ErrorResponse{
+		ErrorMessage: err.Error(),
+		Code:         ConflictCode,
+		StatusCode:   http.StatusConflict,
+	})
+}
+
+// writeError : Write an error response
+func writeError(w http.ResponseWriter, response ErrorResponse) {
 	message, _ := json.Marshal(response)
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(response.StatusCode)
 	_, _ = w.Write(message)
 }
@@ -0,0 +1,99 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : json.go
+ * Creation Date : 18-10-2026
+ */
+
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const unknownFieldPrefix = "json: unknown field "
+
+// DecodeJSON : This is helper function to decode a request body strictly,
+// rejecting empty bodies, unknown fields and anything after the value.
+func DecodeJSON(body io.Reader, value interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		if err == io.EOF {
+			return errors.New("body is empty")
+		}
+		return err
+	}
+
+	if decoder.More() {
+		return errors.New("body holds more than one value")
+	}
+
+	return nil
+}
+
+// MergePatch : This is helper function to apply a JSON merge patch (RFC 7396)
+// to a JSON document. The fields of an object patch replace the ones of the
+// document, objects being patched in turn and null removing a field, any other
+// patch replacing the whole document.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var patchFields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &patchFields); err != nil || patchFields == nil {
+		return patch, nil
+	}
+
+	// Anything but an object is patched as an empty one
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(document, &fields); err != nil || fields == nil {
+		fields = make(map[string]json.RawMessage, len(patchFields))
+	}
+
+	for name, value := range patchFields {
+		if string(bytes.TrimSpace(value)) == "null" {
+			delete(fields, name)
+			continue
+		}
+
+		merged, err := MergePatch(fields[name], value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = merged
+	}
+
+	return json.Marshal(fields)
+}
+
+// unknownField : Name of the unknown field a decoding error is about, if any
+func unknownField(err error) string {
+	if !strings.HasPrefix(err.Error(), unknownFieldPrefix) {
+		return ""
+	}
+
+	field := strings.TrimPrefix(err.Error(), unknownFieldPrefix)
+	if unquoted, err := strconv.Unquote(field); err == nil {
+		return unquoted
+	}
+
+	return field
+}
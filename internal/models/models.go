@@ -22,26 +22,349 @@
 
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // IntervalType : Type of interval
 type IntervalType string
 
+// Hour
 // Day
+// Week (ISO)
 // Month
+// Year
+// Duration (rolling)
+const (
+	HourType     IntervalType = "hour"
+	DayType      IntervalType = "day"
+	WeekType     IntervalType = "week"
+	MonthType    IntervalType = "month"
+	YearType     IntervalType = "year"
+	DurationType IntervalType = "duration"
+)
+
+// UnitType : Type of rate unit
+type UnitType string
+
+// Second
+// Minute
+// Hour
+const (
+	SecondUnit UnitType = "second"
+	MinuteUnit UnitType = "minute"
+	HourUnit   UnitType = "hour"
+)
+
+// AlgorithmType : Type of rate algorithm
+type AlgorithmType string
+
+// Fixed window
+// Sliding window log
+// Sliding window counter
+// Token bucket
+const (
+	FixedWindowType          AlgorithmType = "fixed_window"
+	SlidingWindowLogType     AlgorithmType = "sliding_window_log"
+	SlidingWindowCounterType AlgorithmType = "sliding_window_counter"
+	TokenBucketType          AlgorithmType = "token_bucket"
+)
+
+// ChargeType : Type of charge
+type ChargeType string
+
+// Charge admitted requests only
+// Charge every attempt
+const (
+	OnAdmitCharge     ChargeType = "on_admit"
+	AllAttemptsCharge ChargeType = "all_attempts"
+)
+
+// PolicyType : Type of failure policy
+type PolicyType string
+
+// Admit the request
+// Reject the request
+// Apply the default limit
+const (
+	FailOpenPolicy     PolicyType = "fail_open"
+	FailClosedPolicy   PolicyType = "fail_closed"
+	DefaultLimitPolicy PolicyType = "default_limit"
+)
+
+// SourceType : Origin of a config
+type SourceType string
+
+// Set through the REST API
+// Fetched from the policy service
+const (
+	LocalSource         SourceType = "local"
+	PolicyServiceSource SourceType = "policy_service"
+)
+
+// AuditAction : Type of operation on counters
+type AuditAction string
+
+// Counters reset
+// Credit granted
+// Counter set
+// Counters imported along with a config
 const (
-	DayType   IntervalType = "day"
-	MonthType IntervalType = "month"
+	ResetAction  AuditAction = "reset"
+	CreditAction AuditAction = "credit"
+	SetAction    AuditAction = "set"
+	ImportAction AuditAction = "import"
 )
 
+// ImportStatus : Outcome of the import of a config
+type ImportStatus string
+
+// Config created
+// Config replaced
+const (
+	CreatedStatus ImportStatus = "created"
+	UpdatedStatus ImportStatus = "updated"
+)
+
+// Duration : Duration written as a string, e.g. "90s" or "30d"
+type Duration time.Duration
+
+const day = 24 * time.Hour
+
+// MarshalJSON : Write the duration as a string, in days when whole
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d > 0 && time.Duration(d)%day == 0 {
+		return json.Marshal(fmt.Sprintf("%dd", time.Duration(d)/day))
+	}
+
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON : Read the duration from a string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	// Days are not supported by time.ParseDuration
+	if strings.HasSuffix(raw, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(raw, "d")); err == nil {
+			*d = Duration(time.Duration(days) * day)
+			return nil
+		}
+	}
+
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
 // Quota Struct
 type Quota struct {
 	Number   int          `json:"max_number,omitempty" bson:"max_number,omitempty"`
 	Interval IntervalType `json:"interval_type,omitempty" bson:"interval_type,omitempty"`
+	Duration Duration     `json:"duration,omitempty" bson:"duration,omitempty"`
+	Timezone string       `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Anchor   string       `json:"anchor,omitempty" bson:"anchor,omitempty"`
+}
+
+// Limit Struct
+type Limit struct {
+	Name      string        `json:"name,omitempty" bson:"name,omitempty"`
+	Number    int           `json:"max_number" bson:"max_number"`
+	Unit      UnitType      `json:"unit,omitempty" bson:"unit,omitempty"`
+	Window    Duration      `json:"window,omitempty" bson:"window,omitempty"`
+	Interval  IntervalType  `json:"interval_type,omitempty" bson:"interval_type,omitempty"`
+	Timezone  string        `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Anchor    string        `json:"anchor,omitempty" bson:"anchor,omitempty"`
+	Algorithm AlgorithmType `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	Burst     int           `json:"burst,omitempty" bson:"burst,omitempty"`
 }
 
 // Config Struct
 type Config struct {
-	Enabled bool           `json:"enabled" bson:"enabled"`
-	Quota   Quota          `json:"quota,omitempty" bson:"quota,omitempty"`
-	Rate    int            `json:"rate,omitempty" bson:"rate,omitempty"`
-	Log     map[string]int `json:"log,omitempty" bson:"log,omitempty"`
+	Enabled     bool          `json:"enabled" bson:"enabled"`
+	Quota       Quota         `json:"quota,omitempty" bson:"quota,omitempty"`
+	Rate        int           `json:"rate,omitempty" bson:"rate,omitempty"`
+	RateUnit    UnitType      `json:"rate_unit,omitempty" bson:"rate_unit,omitempty"`
+	RateWindow  Duration      `json:"rate_window,omitempty" bson:"rate_window,omitempty"`
+	Algorithm   AlgorithmType `json:"algorithm,omitempty" bson:"algorithm,omitempty"`
+	Burst       int           `json:"burst,omitempty" bson:"burst,omitempty"`
+	Limits      []Limit       `json:"limits,omitempty" bson:"limits,omitempty"`
+	Descriptors []Descriptor  `json:"descriptors,omitempty" bson:"descriptors,omitempty"`
+	Charge      ChargeType    `json:"charge,omitempty" bson:"charge,omitempty"`
+	Plan        string        `json:"plan,omitempty" bson:"plan,omitempty"`
 }
+
+// StoredConfig Struct : Config along with its origin,
+// and when it was fetched from the policy service
+type StoredConfig struct {
+	Config
+	Source    SourceType `json:"source,omitempty" bson:"source,omitempty"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty" bson:"fetched_at,omitempty"`
+}
+
+// ConfigEntry Struct : Config of a uid, along with its origin
+type ConfigEntry struct {
+	UID string `json:"uid" bson:"uid"`
+	StoredConfig
+}
+
+// ConfigPage Struct : Configs of a page, the cursor of the next page
+// if any and the count of every config matching the filter when asked for
+type ConfigPage struct {
+	Configs    []ConfigEntry `json:"configs" bson:"configs"`
+	NextCursor string        `json:"next_cursor,omitempty" bson:"next_cursor,omitempty"`
+	Total      *int          `json:"total,omitempty" bson:"total,omitempty"`
+}
+
+// ConfigItem Struct : Config of a uid as exported and imported in
+// bulk, along with the state of its counters when asked for
+type ConfigItem struct {
+	ConfigEntry
+	Counters *CounterState `json:"counters,omitempty" bson:"counters,omitempty"`
+}
+
+// CounterState Struct : Hits used in the current window of the limits
+// of a uid, the ones left untouched being omitted, and its credit
+type CounterState struct {
+	Credit int          `json:"credit,omitempty" bson:"credit,omitempty"`
+	Limits []LimitState `json:"limits,omitempty" bson:"limits,omitempty"`
+}
+
+// LimitState Struct : Hits used in the current window of a limit,
+// along with the end of the window for the fixed windows
+type LimitState struct {
+	Name      string     `json:"name" bson:"name"`
+	Used      int        `json:"used" bson:"used"`
+	WindowEnd *time.Time `json:"window_end,omitempty" bson:"window_end,omitempty"`
+}
+
+// ImportResult Struct : Outcome of the import of a config, along
+// with the counters not restored as their window has ended
+type ImportResult struct {
+	Index   int          `json:"index" bson:"index"`
+	UID     string       `json:"uid" bson:"uid"`
+	Status  ImportStatus `json:"status" bson:"status"`
+	Expired []string     `json:"expired,omitempty" bson:"expired,omitempty"`
+}
+
+// ImportReport Struct : Outcome of the import of every config
+type ImportReport struct {
+	Created int            `json:"created" bson:"created"`
+	Updated int            `json:"updated" bson:"updated"`
+	Items   []ImportResult `json:"items" bson:"items"`
+}
+
+// ConfigFilter Struct : Configs to list, every config by default
+type ConfigFilter struct {
+	Enabled  *bool
+	Plan     string
+	Interval IntervalType
+}
+
+// Descriptor Struct : Node of a descriptor tree, matching a request
+// descriptor entry by key and by value, any value when empty
+type Descriptor struct {
+	Key         string       `json:"key" bson:"key"`
+	Value       string       `json:"value,omitempty" bson:"value,omitempty"`
+	Limits      []Limit      `json:"limits,omitempty" bson:"limits,omitempty"`
+	Descriptors []Descriptor `json:"descriptors,omitempty" bson:"descriptors,omitempty"`
+}
+
+// Entry Struct : Entry of a request descriptor
+type Entry struct {
+	Key   string `json:"key" bson:"key"`
+	Value string `json:"value" bson:"value"`
+}
+
+// Policy Struct : What to do with requests without uid, and
+// with requests which cannot be checked
+type Policy struct {
+	MissingUID   PolicyType `json:"missing_uid,omitempty" bson:"missing_uid,omitempty"`
+	Error        PolicyType `json:"error,omitempty" bson:"error,omitempty"`
+	DefaultLimit Limit      `json:"default_limit" bson:"default_limit"`
+	GRPCError    bool       `json:"grpc_error,omitempty" bson:"grpc_error,omitempty"`
+}
+
+// PolicyEvent Struct : Uids changed or revoked by the policy
+// service, identified so that replays are ignored
+type PolicyEvent struct {
+	ID      string   `json:"id" bson:"id"`
+	Changed []string `json:"changed,omitempty" bson:"changed,omitempty"`
+	Revoked []string `json:"revoked,omitempty" bson:"revoked,omitempty"`
+}
+
+// LimitUsage Struct : Current state of a limit, the window is only
+// set for the limits counted in fixed windows, the rolling ones
+// having their nominal window only
+type LimitUsage struct {
+	Name        string        `json:"name" bson:"name"`
+	Algorithm   AlgorithmType `json:"algorithm" bson:"algorithm"`
+	Number      int           `json:"max_number" bson:"max_number"`
+	Window      Duration      `json:"window,omitempty" bson:"window,omitempty"`
+	WindowStart *time.Time    `json:"window_start,omitempty" bson:"window_start,omitempty"`
+	WindowEnd   *time.Time    `json:"window_end,omitempty" bson:"window_end,omitempty"`
+	Used        int           `json:"used" bson:"used"`
+	Remaining   int           `json:"remaining" bson:"remaining"`
+	ResetAt     time.Time     `json:"reset_at" bson:"reset_at"`
+}
+
+// Usage Struct : Current state of every limit of a uid,
+// along with the credit left on top of its quotas
+type Usage struct {
+	UID     string       `json:"uid" bson:"uid"`
+	Enabled bool         `json:"enabled" bson:"enabled"`
+	Credit  int          `json:"credit,omitempty" bson:"credit,omitempty"`
+	Limits  []LimitUsage `json:"limits" bson:"limits"`
+}
+
+// Audit Struct : Who changed counters and why
+type Audit struct {
+	Actor  string `json:"actor,omitempty" bson:"actor,omitempty"`
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// CounterReset Struct : Limits whose current window
+// is reset, every limit when empty
+type CounterReset struct {
+	Audit
+	Limits []string `json:"limits,omitempty" bson:"limits,omitempty"`
+}
+
+// CounterCredit Struct : Hits granted once, taken before the quotas
+type CounterCredit struct {
+	Audit
+	Hits int `json:"hits" bson:"hits"`
+}
+
+// CounterValue Struct : Hits used in the current window of a limit
+type CounterValue struct {
+	Audit
+	Used int `json:"used" bson:"used"`
+}
+
+// AuditEntry Struct : Operation on the counters of a uid
+type AuditEntry struct {
+	Audit
+	Action AuditAction `json:"action" bson:"action"`
+	Limits []string    `json:"limits,omitempty" bson:"limits,omitempty"`
+	Hits   int         `json:"hits,omitempty" bson:"hits,omitempty"`
+	Used   *int        `json:"used,omitempty" bson:"used,omitempty"`
+	At     time.Time   `json:"at" bson:"at"`
+}
+
+// Plans : Plan templates per name
+type Plans map[string]Config
+
+// Costs : Cost of a request per route
+type Costs map[string]int
@@ -66,10 +66,60 @@ func InitializeRouter() *mux.Router {
 	// API
 	router.HandleFunc("/api/v1", CheckAPI).Methods("GET")
 
+	// Plan templates, before the configs which would match a plan named config
+	router.Handle("/api/v1/plans", http.HandlerFunc(controllers.GetPlans)).Methods("GET")
+	router.Handle("/api/v1/plans/{plan}", http.HandlerFunc(controllers.GetPlan)).Methods("GET")
+	router.Handle("/api/v1/plans/{plan}", http.HandlerFunc(controllers.CreateOrUpdatePlan)).Methods("PUT")
+	router.Handle("/api/v1/plans/{plan}", http.HandlerFunc(controllers.DeletePlan)).Methods("DELETE")
+
 	// Rate Service
+	router.Handle("/api/v1/configs", http.HandlerFunc(controllers.ListConfigs)).Methods("GET")
+	router.Handle("/api/v1/configs:bulk", http.HandlerFunc(controllers.ImportConfigs)).Methods("POST")
+	router.Handle("/api/v1/configs:export", http.HandlerFunc(controllers.ExportConfigs)).Methods("GET")
 	router.Handle("/api/v1/{uid}/config", http.HandlerFunc(controllers.GetConfig)).Methods("GET")
 	router.Handle("/api/v1/{uid}/config", http.HandlerFunc(controllers.CreateOrUpdateConfig)).Methods("PUT")
+	router.Handle("/api/v1/{uid}/config", http.HandlerFunc(controllers.PatchConfig)).Methods("PATCH")
 	router.Handle("/api/v1/{uid}/config", http.HandlerFunc(controllers.DeleteConfig)).Methods("DELETE")
+	router.Handle("/api/v1/{uid}/usage", http.HandlerFunc(controllers.GetUsage)).Methods("GET")
+	router.Handle("/api/v1/{uid}/counters/reset", http.HandlerFunc(controllers.ResetCounters)).Methods("POST")
+	router.Handle("/api/v1/{uid}/counters/credit", http.HandlerFunc(controllers.CreditCounters)).Methods("POST")
+	router.Handle("/api/v1/{uid}/counters/{limit}", http.HandlerFunc(controllers.SetCounter)).Methods("PUT")
+	router.Handle("/api/v1/{uid}/audit", http.HandlerFunc(controllers.GetAudit)).Methods("GET")
+
+	// Rate Service, per domain
+	router.Handle("/api/v1/domains/{domain}/configs", http.HandlerFunc(controllers.ListConfigs)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/configs:bulk", http.HandlerFunc(controllers.ImportConfigs)).Methods("POST")
+	router.Handle("/api/v1/domains/{domain}/configs:export", http.HandlerFunc(controllers.ExportConfigs)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/{uid}/config", http.HandlerFunc(controllers.GetConfig)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/{uid}/config", http.HandlerFunc(controllers.CreateOrUpdateConfig)).Methods("PUT")
+	router.Handle("/api/v1/domains/{domain}/{uid}/config", http.HandlerFunc(controllers.PatchConfig)).Methods("PATCH")
+	router.Handle("/api/v1/domains/{domain}/{uid}/config", http.HandlerFunc(controllers.DeleteConfig)).Methods("DELETE")
+	router.Handle("/api/v1/domains/{domain}/{uid}/usage", http.HandlerFunc(controllers.GetUsage)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/{uid}/counters/reset", http.HandlerFunc(controllers.ResetCounters)).Methods("POST")
+	router.Handle("/api/v1/domains/{domain}/{uid}/counters/credit", http.HandlerFunc(controllers.CreditCounters)).Methods("POST")
+	router.Handle("/api/v1/domains/{domain}/{uid}/counters/{limit}", http.HandlerFunc(controllers.SetCounter)).Methods("PUT")
+	router.Handle("/api/v1/domains/{domain}/{uid}/audit", http.HandlerFunc(controllers.GetAudit)).Methods("GET")
+
+	// Route costs
+	router.Handle("/api/v1/costs", http.HandlerFunc(controllers.GetCosts)).Methods("GET")
+	router.Handle("/api/v1/costs", http.HandlerFunc(controllers.SetCosts)).Methods("PUT")
+
+	// Descriptor tree
+	router.Handle("/api/v1/descriptors", http.HandlerFunc(controllers.GetDescriptors)).Methods("GET")
+	router.Handle("/api/v1/descriptors", http.HandlerFunc(controllers.SetDescriptors)).Methods("PUT")
+	router.Handle("/api/v1/domains/{domain}/descriptors", http.HandlerFunc(controllers.GetDescriptors)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/descriptors", http.HandlerFunc(controllers.SetDescriptors)).Methods("PUT")
+
+	// Failure policy
+	router.Handle("/api/v1/policy", http.HandlerFunc(controllers.GetPolicy)).Methods("GET")
+	router.Handle("/api/v1/policy", http.HandlerFunc(controllers.SetPolicy)).Methods("PUT")
+	router.Handle("/api/v1/policy", http.HandlerFunc(controllers.DeletePolicy)).Methods("DELETE")
+	router.Handle("/api/v1/domains/{domain}/policy", http.HandlerFunc(controllers.GetPolicy)).Methods("GET")
+	router.Handle("/api/v1/domains/{domain}/policy", http.HandlerFunc(controllers.SetPolicy)).Methods("PUT")
+	router.Handle("/api/v1/domains/{domain}/policy", http.HandlerFunc(controllers.DeletePolicy)).Methods("DELETE")
+
+	// Policy service hook
+	router.Handle("/api/v1/hooks/policy", http.HandlerFunc(controllers.PolicyHook)).Methods("POST")
 
 	// Metrics
 	if helper.GetConfiguration().MetricsEnabled == "true" {
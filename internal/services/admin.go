@@ -0,0 +1,313 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : admin.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const auditPrefix = "audit"
+
+// Audit entries kept per uid, the oldest ones are dropped
+const auditMax = 1000
+
+// ErrUnknownLimit : The config of the uid has no such limit
+var ErrUnknownLimit = errors.New("Unknown limit")
+
+// Replace the counter of the current window of a limit with the
+// hits used, spread over the window as the checks would have
+// charged them. No hits used resets the counter.
+//
+// KEYS[1]        : counter key
+// KEYS[2]        : previous window key (sliding window counter)
+// ARGV[1]        : current time in milliseconds
+// ARGV[2]        : unique member (sliding window log)
+// ARGV[3]        : algorithm
+// ARGV[4]        : limit
+// ARGV[5]        : window in milliseconds
+// ARGV[6]        : expiry in milliseconds (fixed window and sliding window counter)
+// ARGV[7]        : burst (token bucket)
+// ARGV[8]        : hits used
+var setCounterScript = redis.Script(`
+local now = tonumber(ARGV[1])
+local member = ARGV[2]
+local algorithm = ARGV[3]
+local limit = tonumber(ARGV[4])
+local window = tonumber(ARGV[5])
+local expire_at = tonumber(ARGV[6])
+local burst = tonumber(ARGV[7])
+local used = tonumber(ARGV[8])
+
+redis.call('DEL', KEYS[1])
+
+if algorithm == 'sliding_window_log' then
+	-- A single member weighing the hits used, as charged
+	if used > 0 then
+		redis.call('ZADD', KEYS[1], tostring(now), member .. '*' .. tostring(used))
+		redis.call('PEXPIRE', KEYS[1], tostring(window))
+	end
+elseif algorithm == 'token_bucket' then
+	if used > 0 and limit > 0 then
+		redis.call('HMSET', KEYS[1], 'tokens', tostring(burst - used), 'at', tostring(now))
+		redis.call('PEXPIRE', KEYS[1], tostring(math.ceil(used * window / limit)))
+	end
+else
+	-- The previous window weighs on the sliding window counter
+	if algorithm == 'sliding_window_counter' then
+		redis.call('DEL', KEYS[2])
+	end
+	if used > 0 then
+		redis.call('SET', KEYS[1], tostring(used))
+		redis.call('PEXPIREAT', KEYS[1], tostring(expire_at))
+	end
+end
+
+return 0
+`)
+
+// ------------------------ GLOBAL -------------------- //
+
+// auditKey : Build the Redis key of the audit trail of a uid in a domain
+func auditKey(domain string, uid string) string {
+	return namespace(domain) + auditPrefix + ":" + uid
+}
+
+// ResetCounters : Reset the current window of the given limits of a uid,
+// of every limit when none is given
+func ResetCounters(domain string, uid string, reset models.CounterReset) error {
+	all, err := uidLimits(domain, uid)
+	if err != nil {
+		return err
+	}
+
+	// Check limit names
+	var v violations
+	selected := all
+	if len(reset.Limits) > 0 {
+		selected = make([]models.Limit, 0, len(reset.Limits))
+		for index, name := range reset.Limits {
+			limit, ok := findLimit(all, name)
+			if !ok {
+				v.add(fmt.Sprintf("limits[%d]", index), "unknown limit %q", name)
+				continue
+			}
+			selected = append(selected, limit)
+		}
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	// Reset counters along with the audit trail
+	currentTime := clock()
+	pipe := redis.Client().TxPipeline()
+	names := make([]string, 0, len(selected))
+	for _, limit := range selected {
+		if err := setCounter(pipe, limitCounter(domain, uid, limit, currentTime), 0, currentTime); err != nil {
+			return err
+		}
+		names = append(names, limit.Name)
+	}
+
+	audit(pipe, domain, uid, models.AuditEntry{
+		Audit:  reset.Audit,
+		Action: models.ResetAction,
+		Limits: names,
+	})
+
+	_, err = pipe.Exec(redisContext)
+	return err
+}
+
+// CreditCounters : Grant hits to a uid once, taken by its
+// quotas before their own counters
+func CreditCounters(domain string, uid string, credit models.CounterCredit) error {
+	all, err := uidLimits(domain, uid)
+	if err != nil {
+		return err
+	}
+
+	// Check hits and quotas
+	var v violations
+	if credit.Hits <= 0 {
+		v.add("hits", "must be positive")
+	}
+
+	var quotas bool
+	for _, limit := range all {
+		quotas = quotas || limitCounter(domain, uid, limit, clock()).credited
+	}
+	if !quotas {
+		v.add("hits", "no quota to credit")
+	}
+
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	// Grant hits along with the audit trail
+	pipe := redis.Client().TxPipeline()
+	pipe.IncrBy(redisContext, creditKey(domain, uid), int64(credit.Hits))
+	audit(pipe, domain, uid, models.AuditEntry{
+		Audit:  credit.Audit,
+		Action: models.CreditAction,
+		Hits:   credit.Hits,
+	})
+
+	_, err = pipe.Exec(redisContext)
+	return err
+}
+
+// SetCounter : Set the hits used in the current window of a limit of a uid
+func SetCounter(domain string, uid string, name string, value models.CounterValue) error {
+	all, err := uidLimits(domain, uid)
+	if err != nil {
+		return err
+	}
+
+	limit, ok := findLimit(all, name)
+	if !ok {
+		return ErrUnknownLimit
+	}
+
+	currentTime := clock()
+	c := limitCounter(domain, uid, limit, currentTime)
+
+	// Check hits used
+	var v violations
+	capacity := counterCapacity(c)
+	switch {
+	case value.Used < 0:
+		v.add("used", "must not be negative")
+	case value.Used > capacity:
+		v.add("used", "must not exceed %d", capacity)
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	// Set counter along with the audit trail
+	pipe := redis.Client().TxPipeline()
+	if err := setCounter(pipe, c, value.Used, currentTime); err != nil {
+		return err
+	}
+
+	used := value.Used
+	audit(pipe, domain, uid, models.AuditEntry{
+		Audit:  value.Audit,
+		Action: models.SetAction,
+		Limits: []string{limit.Name},
+		Used:   &used,
+	})
+
+	_, err = pipe.Exec(redisContext)
+	return err
+}
+
+// GetAudit : Operations on the counters of a uid, the latest first
+func GetAudit(domain string, uid string) ([]models.AuditEntry, error) {
+	raws, err := redis.Client().LRange(redisContext, auditKey(domain, uid), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.AuditEntry, 0, len(raws))
+	for _, raw := range raws {
+		var entry models.AuditEntry
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			log.Error("Invalid audit entry ", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// uidLimits : Limits of the config applying to a uid, as Check finds it
+func uidLimits(domain string, uid string) ([]models.Limit, error) {
+	config, err := loadConfig(domain, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return limits(config), nil
+}
+
+// findLimit : Limit of the given name
+func findLimit(all []models.Limit, name string) (models.Limit, bool) {
+	for _, limit := range all {
+		if limit.Name == name {
+			return limit, true
+		}
+	}
+
+	return models.Limit{}, false
+}
+
+// counterCapacity : Hits a counter holds, the burst of a token bucket
+func counterCapacity(c counter) int {
+	if c.algorithm == models.TokenBucketType {
+		return c.burst
+	}
+
+	return c.limit
+}
+
+// setCounter : Queue the replacement of the current window
+// of a counter with the hits used
+func setCounter(pipe redis.Pipeliner, c counter, used int, t time.Time) error {
+	member, err := uniqueMember(t)
+	if err != nil {
+		return err
+	}
+
+	previous, expireAt := counterArgs(c)
+	setCounterScript.Eval(redisContext, pipe, []string{c.key, previous},
+		unixMilliseconds(t), member, string(c.algorithm), c.limit,
+		milliseconds(c.window), expireAt, c.burst, used)
+
+	return nil
+}
+
+// audit : Queue the record of an operation on the counters of a uid,
+// to be written along with the operation itself
+func audit(pipe redis.Pipeliner, domain string, uid string, entry models.AuditEntry) {
+	entry.At = clock()
+	raw, _ := json.Marshal(entry)
+
+	log.Info("Counters of ", uid, " changed ", string(raw))
+
+	// Keep the latest entries only
+	pipe.LPush(redisContext, auditKey(domain, uid), raw)
+	pipe.LTrim(redisContext, auditKey(domain, uid), 0, auditMax-1)
+}
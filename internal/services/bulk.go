@@ -0,0 +1,278 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : bulk.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Configs imported at once at most
+const maxImportItems = 10000
+
+// ------------------------ GLOBAL -------------------- //
+
+// ImportConfigs : Create or update configs of a domain in bulk, all or
+// nothing, along with the counters given with them. Every config is checked
+// as a single one is before any is written, and the counters of a window
+// which has ended since they were exported are not restored.
+func ImportConfigs(domain string, items []models.ConfigItem) (models.ImportReport, error) {
+	report := models.ImportReport{Items: make([]models.ImportResult, 0, len(items))}
+
+	// Check every item
+	var v violations
+	if len(items) > maxImportItems {
+		v.add("items", "must hold at most %d configs", maxImportItems)
+		return report, v.err()
+	}
+
+	currentTime := clock()
+	seen := make(map[string]int, len(items))
+	for index, item := range items {
+		path := fmt.Sprintf("[%d]", index)
+		validateItem(&v, path, domain, item, currentTime)
+
+		if first, ok := seen[item.UID]; ok {
+			v.add(path+".uid", "duplicate of [%d]", first)
+			continue
+		}
+		seen[item.UID] = index
+	}
+
+	if err := v.err(); err != nil || len(items) <= 0 {
+		return report, err
+	}
+
+	// Configs created or replaced
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, configKey(domain, item.UID))
+	}
+
+	existing, err := redis.Client().MGet(redisContext, keys...).Result()
+	if err != nil {
+		return report, err
+	}
+
+	// Write every config and counter at once, along with the audit trail
+	pipe := redis.Client().TxPipeline()
+	for index, item := range items {
+		raw, _ := json.Marshal(item.Config)
+		pipe.Set(redisContext, keys[index], raw, 0)
+
+		result := models.ImportResult{Index: index, UID: item.UID, Status: models.CreatedStatus}
+		if existing[index] != nil {
+			result.Status = models.UpdatedStatus
+			report.Updated++
+		} else {
+			report.Created++
+		}
+
+		// Counters imported are audited along with the other operations
+		if item.Counters != nil {
+			var restored []string
+			restored, result.Expired, err = restoreCounters(pipe, domain, item, currentTime)
+			if err != nil {
+				return models.ImportReport{}, err
+			}
+
+			audit(pipe, domain, item.UID, models.AuditEntry{
+				Action: models.ImportAction,
+				Limits: restored,
+				Hits:   item.Counters.Credit,
+			})
+		}
+
+		report.Items = append(report.Items, result)
+	}
+
+	_, err = pipe.Exec(redisContext)
+	for _, key := range keys {
+		invalidateConfig(key)
+	}
+	if err != nil {
+		return models.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+// ExportConfigs : Configs set on a domain in the format ImportConfigs
+// reads, along with their counters when asked for. Configs fetched from
+// the policy service are not exported, being fetched again where imported.
+func ExportConfigs(domain string, counters bool) ([]models.ConfigItem, error) {
+	items := []models.ConfigItem{}
+
+	for next := uint64(0); ; {
+		var err error
+		next, err = scanConfigs(domain, next, countBatch, func(entry models.ConfigEntry) {
+			if entry.Source == models.LocalSource {
+				items = append(items, models.ConfigItem{ConfigEntry: entry})
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if next == 0 {
+			break
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].UID < items[j].UID
+	})
+
+	if !counters {
+		return items, nil
+	}
+
+	// Counters as the usage report reads them
+	currentTime := clock()
+	for index, item := range items {
+		config, err := resolvePlan(item.Config)
+		if err != nil {
+			log.Error("Config of ", item.UID, " cannot be applied on its plan ", err)
+			continue
+		}
+
+		usage, err := configUsage(domain, item.UID, config, currentTime)
+		if err != nil {
+			return nil, err
+		}
+		items[index].Counters = counterState(usage)
+	}
+
+	return items, nil
+}
+
+// validateItem : Check a config to import along with its counters
+func validateItem(v *violations, path string, domain string, item models.ConfigItem, t time.Time) {
+	if !ValidUID(item.UID) {
+		v.add(path+".uid", "must be an integer, got %q", item.UID)
+	}
+
+	var configViolations violations
+	validateConfig(&configViolations, item.Config)
+	for _, violation := range configViolations {
+		v.add(path+"."+violation.Field, "%s", violation.Message)
+	}
+
+	// Limits of an invalid config are unknown
+	if len(configViolations) > 0 || item.Counters == nil {
+		return
+	}
+
+	path += ".counters"
+	if item.Counters.Credit < 0 {
+		v.add(path+".credit", "must not be negative")
+	}
+
+	config, err := resolvePlan(item.Config)
+	if err != nil {
+		v.add(path, "%v", err)
+		return
+	}
+
+	all := limits(config)
+	for index, state := range item.Counters.Limits {
+		field := fmt.Sprintf("%s.limits[%d]", path, index)
+
+		limit, ok := findLimit(all, state.Name)
+		if !ok {
+			v.add(field+".name", "unknown limit %q", state.Name)
+			continue
+		}
+
+		capacity := counterCapacity(limitCounter(domain, item.UID, limit, t))
+		switch {
+		case state.Used < 0:
+			v.add(field+".used", "must not be negative")
+		case state.Used > capacity:
+			v.add(field+".used", "must not exceed %d", capacity)
+		}
+	}
+}
+
+// restoreCounters : Queue the counters of a config to import along with its
+// credit, returns the limits restored and the ones whose window has ended
+func restoreCounters(pipe redis.Pipeliner, domain string, item models.ConfigItem, t time.Time) ([]string, []string, error) {
+	config, err := resolvePlan(item.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var restored, expired []string
+	all := limits(config)
+	for _, state := range item.Counters.Limits {
+		limit, _ := findLimit(all, state.Name)
+
+		if state.WindowEnd != nil && !state.WindowEnd.After(t) {
+			expired = append(expired, limit.Name)
+			continue
+		}
+
+		if err := setCounter(pipe, limitCounter(domain, item.UID, limit, t), state.Used, t); err != nil {
+			return nil, nil, err
+		}
+		restored = append(restored, limit.Name)
+	}
+
+	if item.Counters.Credit > 0 {
+		pipe.Set(redisContext, creditKey(domain, item.UID), item.Counters.Credit, 0)
+	} else {
+		pipe.Del(redisContext, creditKey(domain, item.UID))
+	}
+
+	return restored, expired, nil
+}
+
+// counterState : State of the counters of a usage report, without
+// the limits left untouched, nil when every one is untouched
+func counterState(usage models.Usage) *models.CounterState {
+	state := models.CounterState{Credit: usage.Credit}
+	for _, limit := range usage.Limits {
+		if limit.Used > 0 {
+			state.Limits = append(state.Limits, models.LimitState{
+				Name:      limit.Name,
+				Used:      limit.Used,
+				WindowEnd: limit.WindowEnd,
+			})
+		}
+	}
+
+	if state.Credit <= 0 && len(state.Limits) <= 0 {
+		return nil
+	}
+
+	return &state
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : cache.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Channel of the keys of the configs, plans and policies which changed
+const invalidationChannel = "invalidations"
+
+// Cached configs are read again after a while, in
+// case an invalidation was lost
+const defaultConfigCacheTTL = 30 * time.Second
+
+// Subscription retried after a while, meanwhile
+// the cache is flushed on every retry
+const subscriptionRetry = time.Second
+
+// Cached configs kept before flushing the cache
+const configCacheMax = 100000
+
+// Configs and plans already read, per key
+var configCache = struct {
+	sync.RWMutex
+	entries    map[string]cachedConfig
+	generation uint64
+}{entries: make(map[string]cachedConfig)}
+
+var subscribeOnce sync.Once
+
+// Metrics for the config cache
+var (
+	configCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rate_service_config_cache_hits_total",
+		Help: "Configs and plans read from the in-process cache.",
+	})
+	configCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "rate_service_config_cache_misses_total",
+		Help: "Configs and plans read from Redis.",
+	})
+	configCacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_service_config_cache_invalidations_total",
+		Help: "Configs and plans invalidated, when written or when told by an instance.",
+	}, []string{"origin"})
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// cachedConfig : Config or plan as read from Redis, missing or not
+type cachedConfig struct {
+	stored models.StoredConfig
+	err    error
+	at     time.Time
+}
+
+// loadStoredConfig : Config or plan of a key, from the in-process cache
+// when read recently. Missing keys are cached as well.
+func loadStoredConfig(key string) (models.StoredConfig, error) {
+	ttl := configCacheTTL()
+	if ttl <= 0 {
+		return readStoredConfig(key)
+	}
+
+	listenInvalidations()
+
+	configCache.RLock()
+	cached, ok := configCache.entries[key]
+	generation := configCache.generation
+	configCache.RUnlock()
+
+	if ok && time.Since(cached.at) < ttl {
+		configCacheHits.Inc()
+		return cached.stored, cached.err
+	}
+
+	configCacheMisses.Inc()
+	stored, err := readStoredConfig(key)
+	if err != nil && !redis.IsNil(err) {
+		return stored, err
+	}
+
+	// Skip what was read before an invalidation
+	configCache.Lock()
+	if configCache.generation == generation {
+		if len(configCache.entries) >= configCacheMax {
+			configCache.entries = make(map[string]cachedConfig)
+		}
+		configCache.entries[key] = cachedConfig{stored: stored, err: err, at: time.Now()}
+	}
+	configCache.Unlock()
+
+	return stored, err
+}
+
+// readStoredConfig : Config or plan of a key, from Redis
+func readStoredConfig(key string) (models.StoredConfig, error) {
+	var stored models.StoredConfig
+	raw, err := redis.Client().Get(redisContext, key).Result()
+	json.Unmarshal([]byte(raw), &stored)
+
+	// Configs without origin were set locally
+	if len(stored.Source) <= 0 {
+		stored.Source = models.LocalSource
+	}
+
+	return stored, err
+}
+
+// invalidateConfig : Drop a config or a plan which changed
+// from the cache of every instance
+func invalidateConfig(key string) {
+	dropCachedConfig(key)
+	configCacheInvalidations.WithLabelValues("write").Inc()
+
+	if err := redis.Client().Publish(redisContext, invalidationChannel, key).Err(); err != nil {
+		log.Debug("Publishing invalidation failed ", err)
+	}
+}
+
+// listenInvalidations : Listen to the invalidations of the other instances
+func listenInvalidations() {
+	subscribeOnce.Do(func() {
+		// Connect before listening, the client is set up once
+		if redis.Client() == nil {
+			return
+		}
+
+		go redis.Subscribe(redisContext, invalidationChannel, subscriptionRetry, receiveInvalidation, flushConfigCache)
+	})
+}
+
+// receiveInvalidation : Drop a config, a plan or a policy
+// which changed on any instance from the cache
+func receiveInvalidation(key string) {
+	dropCachedPolicy(key)
+	dropCachedConfig(key)
+	configCacheInvalidations.WithLabelValues("message").Inc()
+}
+
+// dropCachedConfig : Drop a config or a plan from the cache
+func dropCachedConfig(key string) {
+	configCache.Lock()
+	delete(configCache.entries, key)
+	configCache.generation++
+	configCache.Unlock()
+}
+
+// flushConfigCache : Drop every config and plan from the cache,
+// when invalidations may have been lost
+func flushConfigCache(err error) {
+	if err != nil {
+		log.Debug("Invalidations subscription failed ", err)
+	}
+
+	configCache.Lock()
+	configCache.entries = make(map[string]cachedConfig)
+	configCache.generation++
+	configCache.Unlock()
+}
+
+// configCacheTTL : Time during which a cached config is used
+func configCacheTTL() time.Duration {
+	return time.Duration(intSetting(helper.GetConfiguration().ConfigCacheTTL, int(defaultConfigCacheTTL/time.Second))) * time.Second
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : cost.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const costsKey = "costs"
+const defaultCost = 1
+
+// ------------------------ GLOBAL -------------------- //
+
+// GetCosts : CRUD
+func GetCosts() (models.Costs, error) {
+	// Get costs
+	raw, err := redis.Client().HGetAll(redisContext, costsKey).Result()
+	costs := make(models.Costs, len(raw))
+	for route, val := range raw {
+		costs[route], _ = strconv.Atoi(val)
+	}
+
+	return costs, err
+}
+
+// SetCosts : CRUD, replaces the whole cost table
+func SetCosts(costs models.Costs) error {
+	values := make([]interface{}, 0, 2*len(costs))
+	for route, cost := range costs {
+		values = append(values, route, cost)
+	}
+
+	// Replace costs
+	pipe := redis.Client().TxPipeline()
+	pipe.Del(redisContext, costsKey)
+	if len(values) > 0 {
+		pipe.HMSet(redisContext, costsKey, values...)
+	}
+	_, err := pipe.Exec(redisContext)
+
+	return err
+}
+
+// GetRouteCost : Cost of a request on the given route, one
+// when the route has no cost
+func GetRouteCost(route string) (int, error) {
+	if len(route) <= 0 {
+		return defaultCost, nil
+	}
+
+	cost, err := redis.Client().HGet(redisContext, costsKey, route).Int()
+	if err != nil {
+		if redis.IsNil(err) {
+			return defaultCost, nil
+		}
+		return defaultCost, err
+	}
+
+	return cost, nil
+}
+
+// ValidateCosts : Check a cost table before it is stored
+func ValidateCosts(costs models.Costs) error {
+	routes := make([]string, 0, len(costs))
+	for route := range costs {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	var v violations
+	for _, route := range routes {
+		if costs[route] <= 0 {
+			v.add(route, "cost must be positive")
+		}
+	}
+
+	return v.err()
+}
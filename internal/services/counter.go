@@ -0,0 +1,383 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : counter.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const counterPrefix = "counter"
+
+// Charge reading the counters without taking from them
+const peekCharge models.ChargeType = "peek"
+
+// Counter of the hits granted on top of the quotas
+const creditName = "credit"
+
+// Check every counter can take the cost of the request then charge
+// them all at once, either only when every counter is within its
+// limit or on every attempt. Counters come in groups, one per subject,
+// each with its own credit and charge type. The quotas take the cost
+// from the credit of their group first, and only charge what the
+// credit doesn't cover.
+// Each counter expires once it no longer matters so that stale
+// state is dropped by Redis itself. The sliding window logs hold
+// one member per charge, weighing its cost.
+//
+// ARGV[1]        : current time in milliseconds
+// ARGV[2]        : unique member (sliding window log)
+// ARGV[3]        : cost
+//
+// Then for each group, its keys and arguments following the ones of
+// the previous group:
+//
+// KEYS[1]        : credit key
+// KEYS[2i]       : counter key
+// KEYS[2i + 1]   : previous window key (sliding window counter)
+// ARGV[1]        : charge type, peek to only read the counters
+// ARGV[2]        : number of counters
+// ARGV[6i - 3]   : algorithm
+// ARGV[6i - 2]   : limit
+// ARGV[6i - 1]   : window in milliseconds
+// ARGV[6i]       : expiry in milliseconds (fixed window and sliding window counter)
+// ARGV[6i + 1]   : burst (token bucket)
+// ARGV[6i + 2]   : 1 when taking from the credit first (quota)
+//
+// Returns 0 if every counter is within its limit, the index, across
+// the groups, of the first counter which would go over its limit
+// otherwise, followed by the hits left and the milliseconds until the
+// reset of every counter once charged.
+var chargeScript = redis.Script(`
+local now = tonumber(ARGV[1])
+local member = ARGV[2]
+local cost = tonumber(ARGV[3])
+
+-- Tokens left in a bucket, refilled since it was last taken from
+local function tokens(c)
+	local state = redis.call('HMGET', c.key, 'tokens', 'at')
+	local left = tonumber(state[1]) or c.burst
+	local at = tonumber(state[2]) or now
+	return math.min(c.burst, left + math.max(0, now - at) * c.limit / c.window)
+end
+
+-- Hits logged within the window, each member weighing the cost after its star
+local function logged(c)
+	redis.call('ZREMRANGEBYSCORE', c.key, '-inf', tostring(now - c.window))
+	local hits = 0
+	for _, entry in ipairs(redis.call('ZRANGE', c.key, 0, -1)) do
+		hits = hits + (tonumber(string.match(entry, '%*(%d+)$')) or 1)
+	end
+	return hits
+end
+
+-- Check a counter can take the cost
+local function peek(c)
+	if c.limit <= 0 then
+		return false
+	end
+
+	if c.algorithm == 'sliding_window_log' then
+		return logged(c) + c.cost <= c.limit
+	elseif c.algorithm == 'sliding_window_counter' then
+		local current = tonumber(redis.call('GET', c.key) or '0')
+		local before = tonumber(redis.call('GET', c.previous) or '0')
+		return current + before * (c.window - now % c.window) / c.window + c.cost <= c.limit
+	elseif c.algorithm == 'token_bucket' then
+		return tokens(c) >= c.cost
+	end
+
+	return tonumber(redis.call('GET', c.key) or '0') + c.cost <= c.limit
+end
+
+-- Charge a counter with the cost
+local function take(c)
+	if c.algorithm == 'sliding_window_log' then
+		if c.cost > 0 then
+			redis.call('ZADD', c.key, tostring(now), member .. '*' .. tostring(c.cost))
+			redis.call('PEXPIRE', c.key, tostring(c.window))
+		end
+	elseif c.algorithm == 'token_bucket' then
+		if c.limit > 0 then
+			local left = tokens(c) - c.cost
+			redis.call('HMSET', c.key, 'tokens', tostring(left), 'at', tostring(now))
+			redis.call('PEXPIRE', c.key, tostring(math.ceil((c.burst - left) * c.window / c.limit)))
+		end
+	elseif redis.call('INCRBY', c.key, tostring(c.cost)) == c.cost then
+		redis.call('PEXPIREAT', c.key, tostring(c.expire_at))
+	end
+end
+
+-- Hits left in a counter and milliseconds until it resets
+local function usage(c)
+	if c.limit <= 0 then
+		return 0, 0
+	end
+
+	local left, reset
+	if c.algorithm == 'sliding_window_log' then
+		left = c.limit - logged(c)
+		local oldest = redis.call('ZRANGE', c.key, 0, 0, 'WITHSCORES')
+		reset = oldest[2] and tonumber(oldest[2]) + c.window - now or 0
+	elseif c.algorithm == 'sliding_window_counter' then
+		local current = tonumber(redis.call('GET', c.key) or '0')
+		local before = tonumber(redis.call('GET', c.previous) or '0')
+		left = c.limit - current - before * (c.window - now % c.window) / c.window
+		reset = c.expire_at - c.window - now
+	elseif c.algorithm == 'token_bucket' then
+		left = tokens(c)
+		reset = left < c.burst and math.ceil((math.floor(left) + 1 - left) * c.window / c.limit) or 0
+	else
+		left = c.limit - tonumber(redis.call('GET', c.key) or '0')
+		reset = c.expire_at - now
+	end
+
+	return math.max(0, math.floor(left)), math.max(0, math.ceil(reset))
+end
+
+local groups = {}
+local counters = {}
+local exhausted = 0
+local key, arg = 1, 4
+while key <= #KEYS do
+	-- Part of the cost covered by the credit
+	local credit = tonumber(redis.call('GET', KEYS[key]) or '0')
+	local g = {
+		credit_key = KEYS[key],
+		credit = credit,
+		credited = math.min(credit, cost),
+		charge = ARGV[arg],
+		draws = false,
+		counters = {},
+	}
+	local size = tonumber(ARGV[arg + 1])
+	key, arg = key + 1, arg + 2
+
+	for _ = 1, size do
+		local c = {
+			key = KEYS[key],
+			previous = KEYS[key + 1],
+			algorithm = ARGV[arg],
+			limit = tonumber(ARGV[arg + 1]),
+			window = tonumber(ARGV[arg + 2]),
+			expire_at = tonumber(ARGV[arg + 3]),
+			burst = tonumber(ARGV[arg + 4]),
+			cost = cost,
+		}
+		if ARGV[arg + 5] == '1' then
+			c.cost = cost - g.credited
+			g.draws = true
+		end
+		key, arg = key + 2, arg + 6
+
+		table.insert(g.counters, c)
+		table.insert(counters, c)
+
+		if exhausted == 0 and not peek(c) then
+			exhausted = #counters
+		end
+	end
+
+	table.insert(groups, g)
+end
+
+for _, g in ipairs(groups) do
+	if g.charge ~= 'peek' and (exhausted == 0 or g.charge == 'all_attempts') then
+		for _, c in ipairs(g.counters) do
+			take(c)
+		end
+
+		if g.draws and g.credited > 0 then
+			if g.credited >= g.credit then
+				redis.call('DEL', g.credit_key)
+			else
+				redis.call('DECRBY', g.credit_key, tostring(g.credited))
+			end
+		end
+	end
+end
+
+local results = { exhausted }
+for _, c in ipairs(counters) do
+	local left, reset = usage(c)
+	table.insert(results, left)
+	table.insert(results, reset)
+end
+
+return results
+`)
+
+// ------------------------ GLOBAL -------------------- //
+
+// counter : A single counter charged by Check
+type counter struct {
+	algorithm models.AlgorithmType
+	key       string
+	previous  string
+	limit     int
+	window    time.Duration
+	expireAt  time.Time
+	burst     int
+	credited  bool
+}
+
+// counterKey : Build the Redis key of a counter in a domain
+func counterKey(domain string, uid string, parts ...string) string {
+	return namespace(domain) + strings.Join(append([]string{counterPrefix, uid}, parts...), ":")
+}
+
+// creditKey : Build the Redis key of the credit of a uid in a domain
+func creditKey(domain string, uid string) string {
+	return counterKey(domain, uid, creditName)
+}
+
+// chargeGroup : Counters of a subject charged together, the
+// quotas taking from the credit of the subject first
+type chargeGroup struct {
+	counters   []counter
+	credit     string
+	chargeType models.ChargeType
+}
+
+// charge : Atomically check and charge the counters with the cost at the given
+// time, the quotas taking from the credit first, returns the index (starting at 1)
+// of the first counter over its limit or 0, and the usage of every counter
+func charge(counters []counter, credit string, chargeType models.ChargeType, cost int, t time.Time) (int, []Usage, error) {
+	return chargeGroups([]chargeGroup{{counters: counters, credit: credit, chargeType: chargeType}}, cost, t)
+}
+
+// chargeGroups : Atomically check and charge the counters of every group with
+// the cost at the given time, all or nothing across the groups, returns the
+// index (starting at 1) of the first counter over its limit, counted across
+// the groups, or 0, and the usage of every counter
+func chargeGroups(groups []chargeGroup, cost int, t time.Time) (int, []Usage, error) {
+	// Unique member of the sliding window logs
+	member, err := uniqueMember(t)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// A cost over the capacity of a counter never goes through,
+	// whatever the credit of the quotas, nothing is charged
+	var total int
+	var overCapacity bool
+	for _, g := range groups {
+		total += len(g.counters)
+		for _, c := range g.counters {
+			overCapacity = overCapacity || (!c.credited && cost > counterCapacity(c))
+		}
+	}
+
+	keys := make([]string, 0, len(groups)+2*total)
+	args := make([]interface{}, 0, 3+2*len(groups)+6*total)
+	args = append(args, unixMilliseconds(t), member, cost)
+	for _, g := range groups {
+		chargeType := g.chargeType
+		if overCapacity {
+			chargeType = peekCharge
+		}
+
+		keys = append(keys, g.credit)
+		args = append(args, string(chargeType), len(g.counters))
+
+		for _, c := range g.counters {
+			// Only quotas take from the credit
+			credited := 0
+			if c.credited {
+				credited = 1
+			}
+
+			previous, expireAt := counterArgs(c)
+			keys = append(keys, c.key, previous)
+			args = append(args, string(c.algorithm), c.limit,
+				milliseconds(c.window), expireAt, c.burst, credited)
+		}
+	}
+
+	reply, err := chargeScript.Run(redisContext, redis.Client(), keys, args...).Result()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Exhausted counter then the hits left and reset of each
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 1+2*total {
+		return 0, nil, fmt.Errorf("unexpected charge reply %v", reply)
+	}
+
+	usages := make([]Usage, total)
+	for index := range usages {
+		remaining, _ := values[1+2*index].(int64)
+		reset, _ := values[2+2*index].(int64)
+		usages[index] = Usage{
+			Remaining: int(remaining),
+			Reset:     time.Duration(reset) * time.Millisecond,
+		}
+	}
+
+	over, _ := values[0].(int64)
+	return int(over), usages, nil
+}
+
+// counterArgs : Previous window key of a counter, itself unless a sliding
+// window counter, and its expiry in milliseconds, 0 unless a window counter
+func counterArgs(c counter) (string, int64) {
+	previous := c.previous
+	if len(previous) <= 0 {
+		previous = c.key
+	}
+
+	var expireAt int64
+	if !c.expireAt.IsZero() {
+		expireAt = unixMilliseconds(c.expireAt)
+	}
+
+	return previous, expireAt
+}
+
+// uniqueMember : Unique member of the sliding window logs at the given time
+func uniqueMember(t time.Time) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d-%x", unixMilliseconds(t), nonce), nil
+}
+
+// milliseconds : Duration in milliseconds
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// unixMilliseconds : Unix time in milliseconds
+func unixMilliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,320 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : descriptor.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const descriptorsKey = "descriptors"
+const uidEntryKey = "uid"
+
+// Escapes the separators of the entries within a subject
+var entryEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "=", "%3D")
+
+// ------------------------ GLOBAL -------------------- //
+
+// GetDescriptors : CRUD
+func GetDescriptors(domain string) ([]models.Descriptor, error) {
+	// Get descriptors
+	descriptors := []models.Descriptor{}
+	raw, err := redis.Client().Get(redisContext, namespace(domain)+descriptorsKey).Result()
+	if err != nil {
+		if redis.IsNil(err) {
+			return descriptors, nil
+		}
+		return descriptors, err
+	}
+
+	err = json.Unmarshal([]byte(raw), &descriptors)
+
+	return descriptors, err
+}
+
+// SetDescriptors : CRUD, replaces the whole descriptor tree of a domain
+func SetDescriptors(domain string, descriptors []models.Descriptor) error {
+	// Set descriptors
+	raw, _ := json.Marshal(descriptors)
+	_, err := redis.Client().Set(redisContext, namespace(domain)+descriptorsKey, raw, 0).Result()
+
+	return err
+}
+
+// descriptorCheck : Limits a request descriptor is checked against, charged on
+// the counters of the subject in the domain, none for a disabled config
+type descriptorCheck struct {
+	domain     string
+	subject    string
+	limits     []models.Limit
+	chargeType models.ChargeType
+	disabled   bool
+}
+
+// EvaluateDescriptor : Check if current request, costing the given number of hits,
+// is within the limits matching a request descriptor of a domain. Descriptors with
+// a uid entry match the config of that uid, its own limits or the ones of its
+// descriptor tree, other descriptors match the descriptor tree of the domain.
+// A descriptor matching no limits is within them.
+func EvaluateDescriptor(domain string, entries []models.Entry, hits int) (Result, error) {
+	results, errs := EvaluateDescriptors(domain, [][]models.Entry{entries}, hits)
+
+	return results[0], errs[0]
+}
+
+// EvaluateDescriptors : Check if current request, costing the given number of hits,
+// is within the limits matching every request descriptor of a domain, as
+// EvaluateDescriptor does for each. The limits are charged all or nothing across the
+// descriptors, and the descriptors matching the same limits of the same subject
+// charge them once. A request with a descriptor rejected by a disabled config or
+// which cannot be checked only charges the subjects charged on every attempt, the
+// others being read without charge. Returns the result and the error of every
+// descriptor.
+func EvaluateDescriptors(domain string, descriptors [][]models.Entry, hits int) ([]Result, []error) {
+	results := make([]Result, len(descriptors))
+	errs := make([]error, len(descriptors))
+	if hits <= 0 {
+		hits = 1
+	}
+
+	// Limits of every descriptor, each subject charged once
+	var groups []chargeGroup
+	var checked []descriptorCheck
+	group := make([]int, len(descriptors))
+	subjects := make(map[string]int, len(descriptors))
+	currentTime := clock()
+	var rejected bool
+	for index, entries := range descriptors {
+		results[index] = Result{Exhausted: -1}
+		group[index] = -1
+
+		check, err := checkDescriptor(domain, entries)
+		if err != nil {
+			errs[index] = err
+			rejected = true
+			continue
+		}
+
+		if check.disabled {
+			rejected = true
+			continue
+		}
+
+		if len(check.limits) <= 0 {
+			results[index].OK = true
+			continue
+		}
+
+		results[index].Limits = check.limits
+
+		subject := namespace(check.domain) + check.subject
+		if other, ok := subjects[subject]; ok {
+			group[index] = other
+			continue
+		}
+
+		counters := make([]counter, 0, len(check.limits))
+		for _, limit := range check.limits {
+			counters = append(counters, limitCounter(check.domain, check.subject, limit, currentTime))
+		}
+
+		subjects[subject] = len(groups)
+		group[index] = len(groups)
+		groups = append(groups, chargeGroup{
+			counters:   counters,
+			credit:     creditKey(check.domain, check.subject),
+			chargeType: check.chargeType,
+		})
+		checked = append(checked, check)
+	}
+
+	if len(groups) <= 0 {
+		return results, errs
+	}
+
+	// Nothing is admitted along with a rejected descriptor
+	if rejected {
+		for index := range groups {
+			if groups[index].chargeType != models.AllAttemptsCharge {
+				groups[index].chargeType = peekCharge
+			}
+		}
+	}
+
+	// Charge every subject at once
+	over, usages, err := chargeGroups(groups, hits, currentTime)
+
+	// Result of every subject
+	groupResults := make([]Result, len(groups))
+	var offset int
+	for index, g := range groups {
+		result := Result{Limits: checked[index].limits, Exhausted: -1}
+
+		if err == nil {
+			result.Usages = usages[offset : offset+len(g.counters)]
+			if over > offset && over <= offset+len(g.counters) {
+				result.Exhausted = over - offset - 1
+				log.Debug("Exhausted limit is ", result.Limits[result.Exhausted].Name)
+			}
+			result.OK = result.Exhausted < 0
+		}
+
+		groupResults[index] = result
+		offset += len(g.counters)
+	}
+
+	log.Debug("Answer is ", over == 0)
+
+	for index := range descriptors {
+		if group[index] < 0 {
+			continue
+		}
+
+		results[index] = groupResults[group[index]]
+		if err != nil {
+			errs[index] = unavailable(err)
+		}
+	}
+
+	return results, errs
+}
+
+// checkDescriptor : Limits matching a request descriptor of a domain
+func checkDescriptor(domain string, entries []models.Entry) (descriptorCheck, error) {
+	// Split the uid from the other entries
+	var uid string
+	var found bool
+	rest := make([]models.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Key == uidEntryKey && !found {
+			uid, found = entry.Value, true
+			continue
+		}
+		rest = append(rest, entry)
+	}
+
+	// Descriptor tree of the domain
+	if !found {
+		descriptors, err := GetDescriptors(domain)
+		if err != nil {
+			return descriptorCheck{}, unavailable(err)
+		}
+
+		parts, nodeLimits := matchDescriptor(descriptors, rest)
+
+		return descriptorCheck{
+			domain:  domain,
+			subject: descriptorSubject(descriptorsKey, parts),
+			limits:  nodeLimits,
+		}, nil
+	}
+
+	// Counters follow the domain of the request
+	config, err := loadConfig(domain, uid)
+	if err != nil {
+		return descriptorCheck{}, err
+	}
+
+	// Check if enabled
+	if !config.Enabled {
+		return descriptorCheck{disabled: true}, nil
+	}
+
+	// Most specific limits of the uid descriptor tree, its own otherwise
+	check := descriptorCheck{domain: domain, subject: uid, chargeType: config.Charge}
+	parts, nodeLimits := matchDescriptor(config.Descriptors, rest)
+	if len(nodeLimits) <= 0 {
+		check.limits = limits(config)
+		return check, nil
+	}
+
+	check.subject = descriptorSubject(uid, parts)
+	check.limits = nodeLimits
+
+	return check, nil
+}
+
+// matchDescriptor : Walk down a descriptor tree along the entries and return the
+// deepest node with limits, along with the entries leading to it. The walk stops
+// at the first entry matching no node.
+func matchDescriptor(descriptors []models.Descriptor, entries []models.Entry) ([]string, []models.Limit) {
+	var matchedParts []string
+	var matched []models.Limit
+
+	parts := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		node, ok := findDescriptor(descriptors, entry)
+		if !ok {
+			break
+		}
+
+		parts = append(parts, entryPart(entry))
+		if len(node.Limits) > 0 {
+			matchedParts = append([]string{}, parts...)
+			matched = namedLimits(node.Limits)
+		}
+		descriptors = node.Descriptors
+	}
+
+	return matchedParts, matched
+}
+
+// findDescriptor : Node matching an entry, with the
+// same value first, with any value otherwise
+func findDescriptor(descriptors []models.Descriptor, entry models.Entry) (models.Descriptor, bool) {
+	var wildcard models.Descriptor
+	var found bool
+	for _, descriptor := range descriptors {
+		if descriptor.Key != entry.Key {
+			continue
+		}
+
+		if descriptor.Value == entry.Value {
+			return descriptor, true
+		}
+
+		if len(descriptor.Value) <= 0 && !found {
+			wildcard, found = descriptor, true
+		}
+	}
+
+	return wildcard, found
+}
+
+// descriptorSubject : Counters prefix of the limits of a descriptor
+func descriptorSubject(subject string, parts []string) string {
+	return strings.Join(append([]string{subject}, parts...), ":")
+}
+
+// entryPart : Part of a subject matching an entry, its key and value
+// escaped so that entries holding separators, e.g. IPv6 addresses,
+// don't share the counters of others
+func entryPart(entry models.Entry) string {
+	return entryEscaper.Replace(entry.Key) + "=" + entryEscaper.Replace(entry.Value)
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : domain.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+// ------------------------ GLOBAL -------------------- //
+
+// DefaultDomain : Domain of the requests without one, and of the
+// configs set through the routes without a domain
+const DefaultDomain = "default"
+
+const domainPrefix = "domain"
+const configPrefix = "config"
+
+// ------------------------ GLOBAL -------------------- //
+
+// namespace : Prefix of the keys of a domain, the default
+// domain keeps the keys without prefix
+func namespace(domain string) string {
+	if len(domain) <= 0 || domain == DefaultDomain {
+		return ""
+	}
+
+	return domainPrefix + ":" + domain + ":"
+}
+
+// configKey : Redis key of the config of a uid in a domain,
+// under their own prefix so that they can be scanned
+func configKey(domain string, uid string) string {
+	return namespace(domain) + configPrefix + ":" + uid
+}
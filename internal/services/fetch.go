@@ -0,0 +1,368 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : fetch.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Placeholder of the uid in the endpoint, and the query
+// parameter holding it when there is no placeholder
+const uidPlaceholder = "{uid}"
+const uidParam = "uid"
+
+// Retries of a failed fetch, the first one after the backoff and
+// every next one after twice the previous wait
+const defaultRetries = 2
+const defaultBackoff = 100 * time.Millisecond
+
+// Unknown uids are not asked again for a while
+const defaultUnknownTTL = 60 * time.Second
+
+// Unknown uids kept before pruning the expired ones
+const unknownUIDsMax = 10000
+
+// Fetched configs are fetched again once stale, and
+// still used while being fetched again for a while
+const defaultCacheTTL = 300 * time.Second
+const defaultStaleTTL = 3600 * time.Second
+
+// Largest config body read
+const maxConfigBody = 1 << 20
+
+// ErrUnknownUID : The policy service doesn't know the uid
+var ErrUnknownUID = errors.New("Unknown uid")
+
+// Client shared by every fetch, keeping its connections alive
+var policyClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// Uids the policy service doesn't know, until when
+var unknownUIDs = struct {
+	sync.Mutex
+	until map[string]time.Time
+}{until: make(map[string]time.Time)}
+
+// Uids being fetched again
+var refreshing sync.Map
+
+// ------------------------ GLOBAL -------------------- //
+
+// FetchConfig : If config cannot be found locally, fallback to the policy
+// service hook. The uid replaces the {uid} placeholder of the endpoint, or is
+// sent as the uid query parameter. A 200 returns the config, a 404 or a 410
+// tells that the uid is unknown, which is remembered for a while. Network
+// errors, timeouts, 408, 429 and 5xx are retried with backoff, and match
+// ErrUnavailable once every retry failed, any other status is an error.
+func FetchConfig(uid string) (models.Config, error) {
+	return fetchConfig(uid, true)
+}
+
+// fetchConfig : Fetch a config as FetchConfig does, retrying only when asked.
+// The checks don't wait for the retries, which are left to the background.
+func fetchConfig(uid string, withRetries bool) (models.Config, error) {
+	var config models.Config
+	configuration := helper.GetConfiguration()
+
+	// Check endpoint definition
+	if len(configuration.PolicyServiceEndpoint) <= 0 {
+		return config, errors.New("Policy Service Endpoint not defined")
+	}
+
+	// Check unknown uids
+	if isUnknownUID(uid) {
+		return config, ErrUnknownUID
+	}
+
+	endpoint, err := fetchURL(configuration.PolicyServiceEndpoint, uid)
+	if err != nil {
+		return config, err
+	}
+
+	var retries int
+	if withRetries {
+		retries = intSetting(configuration.PolicyServiceRetries, defaultRetries)
+	}
+	backoff := time.Duration(intSetting(configuration.PolicyServiceBackoff, int(defaultBackoff/time.Millisecond))) * time.Millisecond
+	timeout := time.Duration(intSetting(configuration.PolicyServiceTimeout, defaultTimeout)) * time.Second
+
+	var retry bool
+	for attempt := 0; ; attempt++ {
+		config, retry, err = fetchOnce(endpoint, configuration.PolicyServiceAuthorization, timeout)
+
+		if err == nil || !retry || attempt >= retries {
+			break
+		}
+
+		log.Info("Policy service fetch failed, retrying ", err)
+		time.Sleep(backoff << uint(attempt))
+	}
+
+	switch {
+	case err == ErrUnknownUID:
+		rememberUnknownUID(uid, time.Duration(intSetting(configuration.PolicyServiceUnknownTTL, int(defaultUnknownTTL/time.Second)))*time.Second)
+		return config, err
+	case err != nil && retry:
+		return config, unavailable(err)
+	case err != nil:
+		return config, err
+	}
+
+	// Reject invalid config
+	if err := ValidateConfig(config); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// fetchURL : Endpoint of the config of a uid
+func fetchURL(endpoint string, uid string) (string, error) {
+	if index := strings.Index(endpoint, uidPlaceholder); index >= 0 {
+		escaped := url.PathEscape(uid)
+		if strings.Contains(endpoint[:index], "?") {
+			escaped = url.QueryEscape(uid)
+		}
+		return strings.Replace(endpoint, uidPlaceholder, escaped, -1), nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := parsed.Query()
+	query.Set(uidParam, uid)
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String(), nil
+}
+
+// fetchOnce : Ask the policy service once, and tell
+// whether the failure is worth retrying
+func fetchOnce(endpoint string, authorization string, timeout time.Duration) (models.Config, bool, error) {
+	var config models.Config
+
+	// Create context
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return config, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Add authorization header if specified
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	// Send request
+	resp, err := policyClient.Do(req)
+	if err != nil {
+		return config, true, err
+	}
+
+	// Drain the body so that the connection is reused
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxConfigBody))
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return config, false, ErrUnknownUID
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return config, true, fmt.Errorf("Policy service returned %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return config, false, fmt.Errorf("Policy service returned %d", resp.StatusCode)
+	case err != nil:
+		return config, true, err
+	}
+
+	// Parse data
+	if err := json.Unmarshal(body, &config); err != nil {
+		return config, false, fmt.Errorf("Policy service returned an invalid config: %v", err)
+	}
+
+	return config, false, nil
+}
+
+// cacheFetchedConfig : Store a config fetched from the policy service,
+// until it expires once stale for longer than the stale TTL
+func cacheFetchedConfig(uid string, config models.Config) error {
+	now := clock()
+	raw, _ := json.Marshal(models.StoredConfig{
+		Config:    config,
+		Source:    models.PolicyServiceSource,
+		FetchedAt: &now,
+	})
+
+	// No expiry without cache TTL
+	var expiration time.Duration
+	if ttl := cacheTTL(); ttl > 0 {
+		expiration = ttl + staleTTL()
+	}
+
+	_, err := redis.Client().Set(redisContext, configKey(DefaultDomain, uid), raw, expiration).Result()
+	invalidateConfig(configKey(DefaultDomain, uid))
+
+	return err
+}
+
+// isStale : Whether a config was fetched longer than the cache TTL ago
+func isStale(stored models.StoredConfig) bool {
+	if stored.Source != models.PolicyServiceSource || stored.FetchedAt == nil {
+		return false
+	}
+
+	ttl := cacheTTL()
+
+	return ttl > 0 && clock().Sub(*stored.FetchedAt) >= ttl
+}
+
+// refreshConfig : Fetch a stale config again, once at a time per uid.
+// The config is deleted once the policy service doesn't know the uid
+// anymore, and kept until it expires when the policy service fails.
+func refreshConfig(uid string) {
+	if _, busy := refreshing.LoadOrStore(uid, true); busy {
+		return
+	}
+	defer refreshing.Delete(uid)
+
+	config, err := FetchConfig(uid)
+
+	// Keep the configs set locally meanwhile
+	stored, getErr := GetStoredConfig(DefaultDomain, uid)
+	if getErr == nil && stored.Source != models.PolicyServiceSource {
+		return
+	}
+
+	switch {
+	case err == nil:
+		err = cacheFetchedConfig(uid, config)
+	case err == ErrUnknownUID:
+		err = redis.Client().Del(redisContext, configKey(DefaultDomain, uid)).Err()
+		invalidateConfig(configKey(DefaultDomain, uid))
+	}
+
+	if err != nil {
+		log.Error("Refreshing config failed ", err)
+	}
+}
+
+// cacheTTL : Time after which a fetched config is stale
+func cacheTTL() time.Duration {
+	return time.Duration(intSetting(helper.GetConfiguration().PolicyServiceCacheTTL, int(defaultCacheTTL/time.Second))) * time.Second
+}
+
+// staleTTL : Time during which a stale config is still used
+func staleTTL() time.Duration {
+	return time.Duration(intSetting(helper.GetConfiguration().PolicyServiceStaleTTL, int(defaultStaleTTL/time.Second))) * time.Second
+}
+
+// isUnknownUID : Whether the policy service
+// recently told that it doesn't know a uid
+func isUnknownUID(uid string) bool {
+	unknownUIDs.Lock()
+	defer unknownUIDs.Unlock()
+
+	until, ok := unknownUIDs.until[uid]
+	if ok && clock().After(until) {
+		delete(unknownUIDs.until, uid)
+		return false
+	}
+
+	return ok
+}
+
+// rememberUnknownUID : Remember that the policy
+// service doesn't know a uid for a while
+func rememberUnknownUID(uid string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	unknownUIDs.Lock()
+	defer unknownUIDs.Unlock()
+
+	// Prune the expired uids, keep the known ones otherwise
+	now := clock()
+	if len(unknownUIDs.until) >= unknownUIDsMax {
+		for other, until := range unknownUIDs.until {
+			if now.After(until) {
+				delete(unknownUIDs.until, other)
+			}
+		}
+	}
+
+	if len(unknownUIDs.until) < unknownUIDsMax {
+		unknownUIDs.until[uid] = now.Add(ttl)
+	}
+}
+
+// forgetUnknownUID : Ask the policy service again for a uid
+func forgetUnknownUID(uid string) {
+	unknownUIDs.Lock()
+	defer unknownUIDs.Unlock()
+
+	delete(unknownUIDs.until, uid)
+}
+
+// intSetting : Integer setting, or its default when unset or invalid
+func intSetting(value string, defaultValue int) int {
+	if len(value) <= 0 {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return defaultValue
+	}
+
+	return number
+}
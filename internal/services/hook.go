@@ -0,0 +1,193 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : hook.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const hookPrefix = "hook"
+const signaturePrefix = "sha256="
+
+// Events already applied are remembered for a day
+const hookTTL = 24 * time.Hour
+
+// ErrEventConflict : An event was already received with another body
+var ErrEventConflict = errors.New("Event already received with another body")
+
+// ------------------------ GLOBAL -------------------- //
+
+// hookKey : Key of an event already received
+func hookKey(id string) string {
+	return hookPrefix + ":" + id
+}
+
+// VerifySignature : Check the HMAC SHA-256 signature of a body, written
+// as sha256=hex, against the shared secret of the policy service. Every
+// signature is rejected without shared secret.
+func VerifySignature(body []byte, signature string) bool {
+	secret := helper.GetConfiguration().PolicyServiceHookSecret
+	if len(secret) <= 0 || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	received, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+
+	return hmac.Equal(received, mac.Sum(nil))
+}
+
+// ValidatePolicyEvent : Check an event before it is applied
+func ValidatePolicyEvent(event models.PolicyEvent) error {
+	var v violations
+	if len(event.ID) <= 0 {
+		v.add("id", "is required")
+	}
+
+	for _, field := range []struct {
+		name string
+		uids []string
+	}{{"changed", event.Changed}, {"revoked", event.Revoked}} {
+		for index, uid := range field.uids {
+			if len(uid) <= 0 {
+				v.add(fmt.Sprintf("%s[%d]", field.name, index), "empty uid")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+// ClaimPolicyEvent : Remember an event before it is applied, and tell
+// whether it is new. A replay of the same event isn't, a different
+// event with the same id is a conflict.
+func ClaimPolicyEvent(id string, body []byte) (bool, error) {
+	sum := sha256.Sum256(body)
+	digest := hex.EncodeToString(sum[:])
+
+	claimed, err := redis.Client().SetNX(redisContext, hookKey(id), digest, hookTTL).Result()
+	if err != nil || claimed {
+		return claimed, err
+	}
+
+	received, err := redis.Client().Get(redisContext, hookKey(id)).Result()
+	switch {
+	case redis.IsNil(err):
+		// Expired meanwhile
+		return ClaimPolicyEvent(id, body)
+	case err != nil:
+		return false, err
+	case received != digest:
+		return false, ErrEventConflict
+	}
+
+	return false, nil
+}
+
+// ReleasePolicyEvent : Forget an event which couldn't
+// be applied, so that it can be sent again
+func ReleasePolicyEvent(id string) error {
+	_, err := redis.Client().Del(redisContext, hookKey(id)).Result()
+
+	return err
+}
+
+// ApplyPolicyEvent : Fetch again the configs of the changed uids, and
+// delete the ones of the revoked uids. Only the configs fetched from
+// the policy service are concerned, the ones set locally are kept.
+func ApplyPolicyEvent(event models.PolicyEvent) error {
+	for _, uid := range event.Revoked {
+		forgetUnknownUID(uid)
+
+		if err := deleteFetchedConfig(uid); err != nil {
+			return err
+		}
+	}
+
+	for _, uid := range event.Changed {
+		forgetUnknownUID(uid)
+
+		stored, err := GetStoredConfig(DefaultDomain, uid)
+		switch {
+		case redis.IsNil(err):
+			// Fetched on its next request
+			continue
+		case err != nil:
+			return err
+		case stored.Source != models.PolicyServiceSource:
+			continue
+		}
+
+		config, err := FetchConfig(uid)
+		if err == nil {
+			err = cacheFetchedConfig(uid, config)
+		} else {
+			// Fetched again on its next request
+			log.Error("Fetching changed config failed ", err)
+			err = deleteFetchedConfig(uid)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteFetchedConfig : Delete the config of a uid
+// when it was fetched from the policy service
+func deleteFetchedConfig(uid string) error {
+	stored, err := GetStoredConfig(DefaultDomain, uid)
+	switch {
+	case redis.IsNil(err):
+		return nil
+	case err != nil:
+		return err
+	case stored.Source != models.PolicyServiceSource:
+		return nil
+	}
+
+	_, err = redis.Client().Del(redisContext, configKey(DefaultDomain, uid)).Result()
+	invalidateConfig(configKey(DefaultDomain, uid))
+
+	return err
+}
@@ -0,0 +1,296 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : limit.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	// Embed the time zone database
+	_ "time/tzdata"
+
+	"github.com/bit-broker/rate-service/internal/models"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const rateLimitName = "rate"
+const quotaLimitName = "quota"
+const limitNamePrefix = "limit-"
+const anchorLayout = "2006-01-02"
+
+// Time zones already loaded
+var locations sync.Map
+
+// ------------------------ GLOBAL -------------------- //
+
+// limits : All the limits of a config, the rate and quota shorthands
+// first. Without any limits array both shorthands always apply,
+// otherwise only the ones which are set.
+func limits(config models.Config) []models.Limit {
+	all := make([]models.Limit, 0, 2+len(config.Limits))
+
+	// Rate shorthand
+	if len(config.Limits) <= 0 || config.Rate > 0 {
+		all = append(all, models.Limit{
+			Name:      rateLimitName,
+			Number:    config.Rate,
+			Unit:      config.RateUnit,
+			Window:    config.RateWindow,
+			Algorithm: config.Algorithm,
+			Burst:     config.Burst,
+		})
+	}
+
+	// Quota shorthand
+	if len(config.Limits) <= 0 || config.Quota.Number > 0 {
+		interval := config.Quota.Interval
+		if len(interval) <= 0 {
+			interval = models.MonthType
+		}
+
+		all = append(all, models.Limit{
+			Name:     quotaLimitName,
+			Number:   config.Quota.Number,
+			Interval: interval,
+			Window:   config.Quota.Duration,
+			Timezone: config.Quota.Timezone,
+			Anchor:   config.Quota.Anchor,
+		})
+	}
+
+	return append(all, namedLimits(config.Limits)...)
+}
+
+// namedLimits : Limits array, named after their position by default
+func namedLimits(limits []models.Limit) []models.Limit {
+	named := make([]models.Limit, 0, len(limits))
+	for index, limit := range limits {
+		if len(limit.Name) <= 0 {
+			limit.Name = limitNamePrefix + strconv.Itoa(index)
+		}
+		named = append(named, limit)
+	}
+
+	return named
+}
+
+// limitCounter : Counter enforcing a limit at the given time,
+// calendar intervals are always fixed windows and rolling
+// durations are sliding window logs by default. Both are
+// quotas, taking from the credit first.
+func limitCounter(domain string, uid string, limit models.Limit, t time.Time) counter {
+	if len(limit.Interval) > 0 && limit.Interval != models.DurationType {
+		interval, intervalEnd := intervalBucket(limit, t)
+
+		return counter{
+			algorithm: models.FixedWindowType,
+			key:       counterKey(domain, uid, limit.Name, interval),
+			limit:     limit.Number,
+			expireAt:  intervalEnd,
+			credited:  true,
+		}
+	}
+
+	c := counter{
+		algorithm: limit.Algorithm,
+		limit:     limit.Number,
+		window:    limitWindow(limit),
+		credited:  limit.Interval == models.DurationType,
+	}
+
+	if limit.Interval == models.DurationType && len(c.algorithm) <= 0 {
+		c.algorithm = models.SlidingWindowLogType
+	}
+
+	switch c.algorithm {
+	case models.SlidingWindowLogType:
+		c.key = counterKey(domain, uid, limit.Name, "log")
+	case models.SlidingWindowCounterType:
+		index, indexEnd := windowBucket(c.window, t)
+		c.key = counterKey(domain, uid, limit.Name, windowName(c.window), strconv.FormatInt(index, 10))
+		c.previous = counterKey(domain, uid, limit.Name, windowName(c.window), strconv.FormatInt(index-1, 10))
+		c.expireAt = indexEnd.Add(c.window)
+	case models.TokenBucketType:
+		c.key = counterKey(domain, uid, limit.Name, "bucket")
+		c.burst = limit.Burst
+		if c.burst <= 0 {
+			c.burst = limit.Number
+		}
+	default: // Default is fixed window
+		index, indexEnd := windowBucket(c.window, t)
+		c.key = counterKey(domain, uid, limit.Name, windowName(c.window), strconv.FormatInt(index, 10))
+		c.expireAt = indexEnd
+	}
+
+	return c
+}
+
+// limitWindow : Nominal window of a limit, the explicit window
+// first then the unit, one second by default. Calendar intervals
+// without a fixed length have no window.
+func limitWindow(limit models.Limit) time.Duration {
+	switch limit.Interval {
+	case "", models.DurationType:
+		// Window or unit below
+	case models.HourType:
+		return time.Hour
+	case models.DayType:
+		return 24 * time.Hour
+	case models.WeekType:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+
+	if window := time.Duration(limit.Window); window >= time.Millisecond {
+		return window
+	}
+
+	switch limit.Unit {
+	case models.MinuteUnit:
+		return time.Minute
+	case models.HourUnit:
+		return time.Hour
+	default: // Default is second
+		return time.Second
+	}
+}
+
+// windowBucket : Index of the window holding the given time,
+// counted from the unix epoch, and the end of that window
+func windowBucket(window time.Duration, t time.Time) (int64, time.Time) {
+	index := unixMilliseconds(t) / milliseconds(window)
+	return index, time.Unix(0, 0).Add(time.Duration(index+1) * window)
+}
+
+// windowName : Name of a window in the counter keys
+func windowName(window time.Duration) string {
+	return strconv.FormatInt(milliseconds(window), 10)
+}
+
+// intervalBucket : Calendar interval bucket of the given time, in the
+// time zone of the limit and starting on its anchor, and its end
+func intervalBucket(limit models.Limit, t time.Time) (string, time.Time) {
+	name, _, end := intervalPeriod(limit, t)
+	return name, end
+}
+
+// intervalPeriod : Calendar interval bucket of the given time,
+// along with its start and its end
+func intervalPeriod(limit models.Limit, t time.Time) (string, time.Time, time.Time) {
+	loc, _ := location(limit.Timezone)
+	anchor, anchored := anchorDate(limit.Anchor)
+	t = t.In(loc)
+
+	switch limit.Interval {
+	case models.HourType:
+		// Local hours repeat when the clock goes back, truncate
+		// the absolute time and name the bucket after UTC
+		start := t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+		utc := start.UTC()
+		return fmt.Sprintf(hourLayout, utc.Year(), utc.Month(), utc.Day(), utc.Hour()),
+			start, start.Add(time.Hour)
+	case models.DayType:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return fmt.Sprintf(dayLayout, start.Year(), start.Month(), start.Day()),
+			start, start.AddDate(0, 0, 1)
+	case models.WeekType:
+		// ISO weeks start on monday, anchored ones on the anchor weekday
+		first := time.Monday
+		if anchored {
+			first = anchor.Weekday()
+		}
+		offset := (int(t.Weekday()) - int(first) + 7) % 7
+		start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+		year, week := start.ISOWeek()
+		return fmt.Sprintf(weekLayout, year, week),
+			start, start.AddDate(0, 0, 7)
+	case models.YearType:
+		month, day := time.January, 1
+		if anchored {
+			month, day = anchor.Month(), anchor.Day()
+		}
+		start := anchoredDate(t.Year(), month, day, loc)
+		if t.Before(start) {
+			start = anchoredDate(t.Year()-1, month, day, loc)
+		}
+		return fmt.Sprintf(yearLayout, start.Year()),
+			start, anchoredDate(start.Year()+1, month, day, loc)
+	default: // Default is month
+		day := 1
+		if anchored {
+			day = anchor.Day()
+		}
+		start := anchoredDate(t.Year(), t.Month(), day, loc)
+		if t.Before(start) {
+			previous := time.Date(t.Year(), t.Month()-1, 1, 0, 0, 0, 0, loc)
+			start = anchoredDate(previous.Year(), previous.Month(), day, loc)
+		}
+		next := time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, loc)
+		return fmt.Sprintf(monthLayout, start.Year(), start.Month()),
+			start, anchoredDate(next.Year(), next.Month(), day, loc)
+	}
+}
+
+// anchoredDate : Start of the given day, or of the last day
+// of the month when the month is shorter
+func anchoredDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		day = last
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// anchorDate : Parse the anchor of a limit, if any
+func anchorDate(anchor string) (time.Time, bool) {
+	if len(anchor) <= 0 {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(anchorLayout, anchor)
+	return date, err == nil
+}
+
+// location : Time zone of a limit, UTC by default
+func location(name string) (*time.Location, error) {
+	if len(name) <= 0 {
+		return time.UTC, nil
+	}
+
+	// Check already loaded
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, err
+	}
+
+	locations.Store(name, loc)
+	return loc, nil
+}
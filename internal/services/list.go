@@ -0,0 +1,210 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : list.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// Configs per page by default, and at most
+const defaultPageSize = 100
+const maxPageSize = 1000
+
+// Configs read per batch when counting them
+const countBatch = 1000
+
+// ------------------------ GLOBAL -------------------- //
+
+// ListConfigs : Configs of a domain matching the filter, a page at a time
+// from the cursor, the first page without cursor. Configs are scanned, so
+// a page may hold a few more configs than asked, or fewer when filtered,
+// and a config changed meanwhile may be listed twice or not at all. Counting
+// every config matching the filter scans the whole domain, so the total is
+// only given when asked for.
+func ListConfigs(domain string, filter models.ConfigFilter, cursor string, size int, total bool) (models.ConfigPage, error) {
+	page := models.ConfigPage{Configs: []models.ConfigEntry{}}
+
+	// Check filter and page
+	var v violations
+	switch filter.Interval {
+	case "", models.HourType, models.DayType, models.WeekType, models.MonthType, models.YearType, models.DurationType:
+	default:
+		v.add("interval_type", "unknown interval type %q", filter.Interval)
+	}
+
+	var start uint64
+	var err error
+	if len(cursor) > 0 {
+		if start, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			v.add("cursor", "invalid cursor %q", cursor)
+		}
+	}
+
+	switch {
+	case size == 0:
+		size = defaultPageSize
+	case size < 0 || size > maxPageSize:
+		v.add("limit", "must be between 1 and %d", maxPageSize)
+	}
+
+	if err := v.err(); err != nil {
+		return page, err
+	}
+
+	// Scan until the page is full
+	next := start
+	for {
+		next, err = scanConfigs(domain, next, int64(size-len(page.Configs)), func(entry models.ConfigEntry) {
+			if matchConfig(filter, entry) {
+				page.Configs = append(page.Configs, entry)
+			}
+		})
+
+		if err != nil {
+			return page, err
+		}
+
+		if next == 0 || len(page.Configs) >= size {
+			break
+		}
+	}
+
+	if next != 0 {
+		page.NextCursor = strconv.FormatUint(next, 10)
+	}
+
+	sort.Slice(page.Configs, func(i, j int) bool {
+		return page.Configs[i].UID < page.Configs[j].UID
+	})
+
+	if !total {
+		return page, nil
+	}
+
+	// Count every config matching the filter
+	var count int
+	for next = 0; ; {
+		next, err = scanConfigs(domain, next, countBatch, func(entry models.ConfigEntry) {
+			if matchConfig(filter, entry) {
+				count++
+			}
+		})
+
+		if err != nil {
+			return page, err
+		}
+
+		if next == 0 {
+			page.Total = &count
+			return page, nil
+		}
+	}
+}
+
+// scanConfigs : Visit the configs of a domain of a single scan
+// batch from the cursor, returns the cursor of the next batch
+func scanConfigs(domain string, cursor uint64, count int64, visit func(entry models.ConfigEntry)) (uint64, error) {
+	prefix := configKey(domain, "")
+	keys, next, err := redis.Client().Scan(redisContext, cursor, globEscape(prefix)+"*", count).Result()
+	if err != nil || len(keys) <= 0 {
+		return next, err
+	}
+
+	raws, err := redis.Client().MGet(redisContext, keys...).Result()
+	if err != nil {
+		return next, err
+	}
+
+	for index, raw := range raws {
+		// Deleted meanwhile
+		value, ok := raw.(string)
+		if !ok {
+			continue
+		}
+
+		entry := models.ConfigEntry{UID: strings.TrimPrefix(keys[index], prefix)}
+		if err := json.Unmarshal([]byte(value), &entry.StoredConfig); err != nil {
+			continue
+		}
+
+		// Configs without origin were set locally
+		if len(entry.Source) <= 0 {
+			entry.Source = models.LocalSource
+		}
+
+		visit(entry)
+	}
+
+	return next, nil
+}
+
+// matchConfig : Whether a config matches the filter, the plan filter matches
+// the plan the config references, the others the config applied on its plan
+func matchConfig(filter models.ConfigFilter, entry models.ConfigEntry) bool {
+	if len(filter.Plan) > 0 && entry.Plan != filter.Plan {
+		return false
+	}
+
+	config, err := resolvePlan(entry.Config)
+	if err != nil {
+		config = entry.Config
+	}
+
+	if filter.Enabled != nil && config.Enabled != *filter.Enabled {
+		return false
+	}
+
+	if len(filter.Interval) <= 0 {
+		return true
+	}
+
+	for _, limit := range limits(config) {
+		if limit.Interval == filter.Interval {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globEscape : Escape the special characters of a SCAN pattern
+func globEscape(pattern string) string {
+	var escaped strings.Builder
+	for _, char := range pattern {
+		switch char {
+		case '*', '?', '[', ']', '\\':
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+
+	return escaped.String()
+}
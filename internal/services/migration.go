@@ -0,0 +1,198 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : migration.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const migrationBatch = 100
+
+// Set once every config stored under its bare uid was moved
+const configKeysMigrated = "migration:config-keys"
+
+// Set once the legacy log of every config was migrated
+const legacyLogsMigrated = "migration:legacy-logs"
+
+// ------------------------ GLOBAL -------------------- //
+
+// legacyConfig : Config as stored before the counters
+// were moved to their own keys
+type legacyConfig struct {
+	models.Config
+	Log map[string]int `json:"log,omitempty"`
+}
+
+// MigrateConfigKeys : Move the configs stored under their bare uid to
+// their own prefix, anything else is left untouched. The keyspace is
+// scanned until every config was moved, once.
+func MigrateConfigKeys() error {
+	done, err := redis.Client().Exists(redisContext, configKeysMigrated).Result()
+	if err != nil || done > 0 {
+		return err
+	}
+
+	if err := scanKeys("*", migrateConfigKey); err != nil {
+		return err
+	}
+
+	return redis.Client().Set(redisContext, configKeysMigrated, clock().Format(time.RFC3339), 0).Err()
+}
+
+// migrateConfigKey : Move a single config, unless it was already moved.
+// The keys of the service are prefixed, the configs of other domains
+// always were, and the configs of uids which aren't integers are kept.
+func migrateConfigKey(uid string) error {
+	if strings.Contains(uid, ":") {
+		return nil
+	}
+
+	raw, err := redis.Client().Get(redisContext, uid).Result()
+	if err != nil || !isLegacyConfig(raw) {
+		return nil
+	}
+
+	if !ValidUID(uid) {
+		log.Error("Config of a uid which isn't an integer not moved, legacy key kept ", uid)
+		return nil
+	}
+
+	key := configKey(DefaultDomain, uid)
+	moved, err := redis.Client().RenameNX(redisContext, uid, key).Result()
+	if err != nil {
+		return err
+	}
+
+	if !moved {
+		log.Info("Config already moved, legacy key kept ", uid)
+		return nil
+	}
+
+	invalidateConfig(key)
+	log.Info("Moved config of ", uid)
+
+	return nil
+}
+
+// isLegacyConfig : Whether a value is a config, along with its legacy
+// log if any, holding the fields of a config only and at least enabled
+func isLegacyConfig(raw string) bool {
+	var config legacyConfig
+	if err := helper.DecodeJSON(strings.NewReader(raw), &config); err != nil {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return false
+	}
+	_, ok := fields["enabled"]
+
+	return ok
+}
+
+// MigrateLegacyLogs : Move the current quota counter out of the
+// legacy log of every config, and store the configs without it.
+// The configs are scanned until every log was migrated, once.
+func MigrateLegacyLogs() error {
+	done, err := redis.Client().Exists(redisContext, legacyLogsMigrated).Result()
+	if err != nil || done > 0 {
+		return err
+	}
+
+	err = scanKeys(configPrefix+":*", func(key string) error {
+		return migrateLegacyLog(strings.TrimPrefix(key, configPrefix+":"))
+	})
+	if err != nil {
+		return err
+	}
+
+	return redis.Client().Set(redisContext, legacyLogsMigrated, clock().Format(time.RFC3339), 0).Err()
+}
+
+// scanKeys : Visit every key matching the pattern, batch by batch
+func scanKeys(match string, visit func(key string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := redis.Client().Scan(redisContext, cursor, match, migrationBatch).Result()
+
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := visit(key); err != nil {
+				return err
+			}
+		}
+
+		// Check end of scan
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// migrateLegacyLog : Migrate a single config, anything
+// else than a legacy config is left untouched
+func migrateLegacyLog(uid string) error {
+	// Get legacy config
+	raw, err := redis.Client().Get(redisContext, configKey(DefaultDomain, uid)).Result()
+	if err != nil {
+		return nil
+	}
+
+	var config legacyConfig
+	if err := json.Unmarshal([]byte(raw), &config); err != nil || config.Log == nil {
+		return nil
+	}
+
+	// Carry over the current quota counter, older buckets are dropped
+	// Legacy buckets were in the local time zone
+	currentTime := clock()
+	legacyInterval, _ := intervalBucket(models.Limit{Interval: config.Quota.Interval, Timezone: "Local"}, currentTime)
+	interval, intervalEnd := intervalBucket(models.Limit{Interval: config.Quota.Interval}, currentTime)
+	if val, ok := config.Log[legacyInterval]; ok {
+		key := counterKey(DefaultDomain, uid, quotaLimitName, interval)
+		_, err := redis.Client().SetNX(redisContext, key, val, intervalEnd.Sub(currentTime)).Result()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info("Migrated legacy log of ", uid)
+
+	// Save config without log
+	return CreateOrUpdateConfig(uid, config.Config)
+}
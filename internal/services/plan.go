@@ -0,0 +1,237 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : plan.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const planPrefix = "plan"
+
+// ErrPlanInUse : Plan referenced by a config or set as the default plan
+var ErrPlanInUse = errors.New("Plan in use")
+
+// ------------------------ GLOBAL -------------------- //
+
+// planKey : Redis key of a plan template
+func planKey(name string) string {
+	return planPrefix + ":" + name
+}
+
+// GetPlans : CRUD
+func GetPlans() (models.Plans, error) {
+	plans := make(models.Plans)
+
+	var cursor uint64
+	for {
+		keys, next, err := redis.Client().Scan(redisContext, cursor, planKey("*"), migrationBatch).Result()
+
+		if err != nil {
+			return plans, err
+		}
+
+		for _, key := range keys {
+			name := strings.TrimPrefix(key, planKey(""))
+			if plan, err := GetPlan(name); err == nil {
+				plans[name] = plan
+			}
+		}
+
+		// Check end of scan
+		if next == 0 {
+			return plans, nil
+		}
+		cursor = next
+	}
+}
+
+// GetPlan : CRUD
+func GetPlan(name string) (models.Config, error) {
+	// Get plan
+	var plan models.Config
+	raw, err := redis.Client().Get(redisContext, planKey(name)).Result()
+	json.Unmarshal([]byte(raw), &plan)
+
+	return plan, err
+}
+
+// CreateOrUpdatePlan : CRUD, every config referencing the plan follows it
+func CreateOrUpdatePlan(name string, plan models.Config) error {
+	// Set plan
+	raw, _ := json.Marshal(plan)
+	_, err := redis.Client().Set(redisContext, planKey(name), raw, 0).Result()
+	invalidateConfig(planKey(name))
+
+	return err
+}
+
+// DeletePlan : CRUD, a plan referenced by a config of any domain or set
+// as the default plan is kept
+func DeletePlan(name string) error {
+	// Check references
+	if err := planReferences(name); err != nil {
+		return err
+	}
+
+	// Delete plan
+	_, err := redis.Client().Del(redisContext, planKey(name)).Result()
+	invalidateConfig(planKey(name))
+
+	return err
+}
+
+// planReferences : ErrPlanInUse along with the first reference to a plan,
+// the default plan or a config of any domain, nil without reference
+func planReferences(name string) error {
+	if helper.GetConfiguration().DefaultPlan == name {
+		return fmt.Errorf("%w as the default plan", ErrPlanInUse)
+	}
+
+	for _, pattern := range []string{configKey(DefaultDomain, "*"), configKey("*", "*")} {
+		var cursor uint64
+		for {
+			keys, next, err := redis.Client().Scan(redisContext, cursor, pattern, migrationBatch).Result()
+			if err != nil {
+				return err
+			}
+
+			if len(keys) > 0 {
+				raws, err := redis.Client().MGet(redisContext, keys...).Result()
+				if err != nil {
+					return err
+				}
+
+				for index, raw := range raws {
+					// Deleted meanwhile
+					value, ok := raw.(string)
+					if !ok {
+						continue
+					}
+
+					var stored models.StoredConfig
+					if json.Unmarshal([]byte(value), &stored) == nil && stored.Plan == name {
+						return fmt.Errorf("%w by %s", ErrPlanInUse, keys[index])
+					}
+				}
+			}
+
+			// Check end of scan
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	return nil
+}
+
+// ValidatePlan : Check a plan template before it is stored
+func ValidatePlan(name string, plan models.Config) error {
+	var v violations
+	if len(name) <= 0 || strings.ContainsAny(name, ":*?[]") {
+		v.add("name", "plan name %q is invalid", name)
+	}
+
+	if len(plan.Plan) > 0 {
+		v.add("plan", "plans cannot reference another plan")
+	}
+
+	validateConfig(&v, plan)
+
+	return v.err()
+}
+
+// defaultPlanConfig : Config of the uids without one, referencing the
+// default plan, if any
+func defaultPlanConfig() (models.Config, bool) {
+	name := helper.GetConfiguration().DefaultPlan
+	if len(name) <= 0 {
+		return models.Config{}, false
+	}
+
+	return models.Config{Enabled: true, Plan: name}, true
+}
+
+// resolvePlan : Config of a uid on top of the plan it references, if any
+func resolvePlan(config models.Config) (models.Config, error) {
+	if len(config.Plan) <= 0 {
+		return config, nil
+	}
+
+	plan, err := loadStoredConfig(planKey(config.Plan))
+	if err != nil {
+		if redis.IsNil(err) {
+			return config, fmt.Errorf("unknown plan %q", config.Plan)
+		}
+		return config, unavailable(err)
+	}
+
+	return applyPlan(config, plan.Config), nil
+}
+
+// applyPlan : Config of a uid on top of a plan, the
+// fields set in the config override the ones of the plan
+func applyPlan(config models.Config, plan models.Config) models.Config {
+	plan.Enabled = config.Enabled
+	plan.Plan = config.Plan
+
+	if config.Rate > 0 {
+		plan.Rate = config.Rate
+	}
+	if len(config.RateUnit) > 0 {
+		plan.RateUnit = config.RateUnit
+	}
+	if config.RateWindow > 0 {
+		plan.RateWindow = config.RateWindow
+	}
+	if len(config.Algorithm) > 0 {
+		plan.Algorithm = config.Algorithm
+	}
+	if config.Burst > 0 {
+		plan.Burst = config.Burst
+	}
+	if config.Quota.Number > 0 {
+		plan.Quota = config.Quota
+	}
+	if len(config.Limits) > 0 {
+		plan.Limits = config.Limits
+	}
+	if len(config.Descriptors) > 0 {
+		plan.Descriptors = config.Descriptors
+	}
+	if len(config.Charge) > 0 {
+		plan.Charge = config.Charge
+	}
+
+	return plan
+}
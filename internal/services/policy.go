@@ -0,0 +1,306 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : policy.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/log"
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+const policyKey = "policy"
+const anonymousUID = "anonymous"
+const defaultLimitName = "default"
+
+// Policies are read again once stale, and
+// quickly given up when Redis is unreachable
+const policyTTL = 30 * time.Second
+const policyTimeout = 100 * time.Millisecond
+
+// Local windows kept before pruning the stale ones
+const localWindowsMax = 10000
+
+// Policies already read, per key
+var policies sync.Map
+
+// In-process windows of the default limit, used while Redis is unreachable
+var localWindows = struct {
+	sync.Mutex
+	windows map[string]*localWindow
+}{windows: make(map[string]*localWindow)}
+
+// ------------------------ GLOBAL -------------------- //
+
+// cachedPolicy : Policy of a domain as read from Redis
+type cachedPolicy struct {
+	policy models.Policy
+	found  bool
+	at     time.Time
+}
+
+// localWindow : Hits of an in-process fixed window
+type localWindow struct {
+	index string
+	hits  int
+}
+
+// GetPolicy : CRUD
+func GetPolicy(domain string) (models.Policy, error) {
+	// Get policy
+	var policy models.Policy
+	raw, err := redis.Client().Get(redisContext, namespace(domain)+policyKey).Result()
+	if err != nil {
+		return policy, err
+	}
+
+	err = json.Unmarshal([]byte(raw), &policy)
+
+	return policy, err
+}
+
+// SetPolicy : CRUD
+func SetPolicy(domain string, policy models.Policy) error {
+	// Set policy
+	raw, _ := json.Marshal(policy)
+	_, err := redis.Client().Set(redisContext, namespace(domain)+policyKey, raw, 0).Result()
+
+	if err == nil {
+		invalidatePolicy(domain)
+	}
+
+	return err
+}
+
+// DeletePolicy : CRUD
+func DeletePolicy(domain string) error {
+	// Delete policy
+	_, err := redis.Client().Del(redisContext, namespace(domain)+policyKey).Result()
+
+	if err == nil {
+		invalidatePolicy(domain)
+	}
+
+	return err
+}
+
+// invalidatePolicy : Drop a policy which changed from the cache of every
+// instance, on the channel of the configs
+func invalidatePolicy(domain string) {
+	key := namespace(domain) + policyKey
+	policies.Delete(key)
+
+	if err := redis.Client().Publish(redisContext, invalidationChannel, key).Err(); err != nil {
+		log.Debug("Publishing invalidation failed ", err)
+	}
+}
+
+// dropCachedPolicy : Drop a policy from the cache, any other key is ignored
+func dropCachedPolicy(key string) {
+	policies.Delete(key)
+}
+
+// GlobalPolicy : Policy of the domains without their own, from the
+// environment, failing closed by default
+func GlobalPolicy() models.Policy {
+	configuration := helper.GetConfiguration()
+	policy := models.Policy{
+		MissingUID: models.PolicyType(configuration.MissingUIDPolicy),
+		Error:      models.PolicyType(configuration.ErrorPolicy),
+		GRPCError:  configuration.ErrorGRPCStatus == "true",
+		DefaultLimit: models.Limit{
+			Name: defaultLimitName,
+			Unit: models.UnitType(configuration.DefaultLimitUnit),
+		},
+	}
+	policy.DefaultLimit.Number, _ = strconv.Atoi(configuration.DefaultLimit)
+
+	return policy
+}
+
+// FailurePolicy : Policy of a domain, its own if any or the global one.
+// Policies are cached for a while, or until changed on any instance, and
+// the last one read is kept while Redis is unreachable.
+func FailurePolicy(domain string) models.Policy {
+	listenInvalidations()
+
+	key := namespace(domain) + policyKey
+	cached, ok := policies.Load(key)
+	if ok && time.Since(cached.(cachedPolicy).at) < policyTTL {
+		return effectivePolicy(cached.(cachedPolicy))
+	}
+
+	// Read it again, quickly
+	ctx, cancel := context.WithTimeout(redisContext, policyTimeout)
+	defer cancel()
+
+	raw, err := redis.Client().Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		var policy models.Policy
+		if err := json.Unmarshal([]byte(raw), &policy); err == nil {
+			cached, ok = cachedPolicy{policy: policy, found: true, at: time.Now()}, true
+			policies.Store(key, cached)
+		}
+	case redis.IsNil(err):
+		cached, ok = cachedPolicy{at: time.Now()}, true
+		policies.Store(key, cached)
+	}
+
+	if !ok {
+		return GlobalPolicy()
+	}
+
+	return effectivePolicy(cached.(cachedPolicy))
+}
+
+// effectivePolicy : Cached policy if found, the global one otherwise
+func effectivePolicy(cached cachedPolicy) models.Policy {
+	if !cached.found {
+		return GlobalPolicy()
+	}
+
+	return cached.policy
+}
+
+// ValidatePolicy : Check a policy before it is stored
+func ValidatePolicy(policy models.Policy) error {
+	var v violations
+	for _, field := range []struct {
+		name       string
+		policyType models.PolicyType
+	}{{"missing_uid", policy.MissingUID}, {"error", policy.Error}} {
+		switch field.policyType {
+		case "", models.FailOpenPolicy, models.FailClosedPolicy:
+		case models.DefaultLimitPolicy:
+			if policy.DefaultLimit.Number <= 0 {
+				v.add("default_limit.max_number", "must be positive with the %s policy %q", field.name, field.policyType)
+			}
+		default:
+			v.add(field.name, "unknown policy type %q", field.policyType)
+		}
+	}
+
+	validateLimit(&v, "default_limit", policy.DefaultLimit)
+
+	return v.err()
+}
+
+// EvaluateAnonymous : Check if current request without uid, costing the given
+// number of hits, is within the default limit of the policy, shared by every
+// request without uid of the domain
+func EvaluateAnonymous(domain string, hits int, policy models.Policy) (Result, error) {
+	if hits <= 0 {
+		hits = 1
+	}
+
+	return evaluateLimits(domain, anonymousUID, []models.Limit{defaultLimit(policy)}, "", hits)
+}
+
+// EvaluateFailure : Outcome of a request which couldn't be checked, admitted,
+// rejected or checked against the default limit of the policy in process,
+// counted per domain and descriptor
+func EvaluateFailure(domain string, entries []models.Entry, hits int, policy models.Policy) Result {
+	switch policy.Error {
+	case models.FailOpenPolicy:
+		return Result{OK: true, Exhausted: -1}
+	case models.DefaultLimitPolicy:
+		parts := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			parts = append(parts, entryPart(entry))
+		}
+		return evaluateLocal(namespace(domain)+strings.Join(parts, ":"), defaultLimit(policy), hits)
+	default: // Default is fail closed
+		return Result{Exhausted: -1}
+	}
+}
+
+// defaultLimit : Default limit of a policy, named
+func defaultLimit(policy models.Policy) models.Limit {
+	limit := policy.DefaultLimit
+	if len(limit.Name) <= 0 {
+		limit.Name = defaultLimitName
+	}
+
+	return limit
+}
+
+// evaluateLocal : Check and charge an in-process fixed window of the limit
+func evaluateLocal(key string, limit models.Limit, hits int) Result {
+	if hits <= 0 {
+		hits = 1
+	}
+
+	// Same windows as the counters, calendar intervals included
+	currentTime := clock()
+	var index string
+	var indexEnd time.Time
+	if len(limit.Interval) > 0 && limit.Interval != models.DurationType {
+		index, indexEnd = intervalBucket(limit, currentTime)
+	} else {
+		var windowIndex int64
+		windowIndex, indexEnd = windowBucket(limitWindow(limit), currentTime)
+		index = strconv.FormatInt(windowIndex, 10)
+	}
+
+	localWindows.Lock()
+	defer localWindows.Unlock()
+
+	// Prune the stale windows
+	if len(localWindows.windows) >= localWindowsMax {
+		for other, window := range localWindows.windows {
+			if window.index != index {
+				delete(localWindows.windows, other)
+			}
+		}
+	}
+
+	window, ok := localWindows.windows[key]
+	if !ok || window.index != index {
+		window = &localWindow{index: index}
+		localWindows.windows[key] = window
+	}
+
+	result := Result{Limits: []models.Limit{limit}, Exhausted: 0}
+	if window.hits+hits <= limit.Number {
+		window.hits += hits
+		result.OK, result.Exhausted = true, -1
+	}
+
+	remaining := limit.Number - window.hits
+	if remaining < 0 {
+		remaining = 0
+	}
+	result.Usages = []Usage{{Remaining: remaining, Reset: indexEnd.Sub(currentTime)}}
+
+	return result
+}
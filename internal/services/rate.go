@@ -26,13 +26,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strconv"
 	"time"
 
-	"github.com/bit-broker/rate-service/internal/helper"
 	"github.com/bit-broker/rate-service/internal/models"
 
 	"github.com/bit-broker/rate-service/pkg/log"
@@ -43,165 +38,283 @@ import (
 
 var redisContext = context.Background()
 
+const hourLayout = "%d-%02d-%02dT%02d"
 const dayLayout = "%d-%02d-%02d"
+const weekLayout = "%d-W%02d"
 const monthLayout = "%d-%02d"
+const yearLayout = "%d"
 const defaultTimeout = 5
 
+// Current time, can be replaced for tests
+var clock = time.Now
+
+// ErrUnavailable : Redis or the policy service cannot be reached
+var ErrUnavailable = errors.New("Unavailable")
+
 // ------------------------ GLOBAL -------------------- //
 
+// unavailableError : Failure of Redis or of the policy service,
+// as opposed to a uid without config, matching ErrUnavailable
+type unavailableError struct {
+	cause error
+}
+
+// Error : Message of the failure
+func (e unavailableError) Error() string {
+	return e.cause.Error()
+}
+
+// Is : Whether the target is ErrUnavailable
+func (e unavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// Unwrap : Failure of Redis or of the policy service
+func (e unavailableError) Unwrap() error {
+	return e.cause
+}
+
+// unavailable : Failure of Redis or of the policy service as
+// an error matching ErrUnavailable, nil without failure
+func unavailable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return unavailableError{cause: err}
+}
+
+// Result : Outcome of a check, with the limits checked, their
+// usage once charged and the index of the exhausted one if any
+type Result struct {
+	OK        bool
+	Limits    []models.Limit
+	Usages    []Usage
+	Exhausted int
+}
+
+// Usage : State of a limit after a check, the
+// hits it still admits and the time until it resets
+type Usage struct {
+	Remaining int
+	Reset     time.Duration
+}
+
+// Current : Limit reported for the check, the exhausted one or the
+// one with the fewest hits left otherwise, with its nominal window
+func (r Result) Current() (models.Limit, time.Duration, bool) {
+	index := r.current()
+	if index < 0 {
+		return models.Limit{}, 0, false
+	}
+
+	return r.Limits[index], limitWindow(r.Limits[index]), true
+}
+
+// CurrentUsage : Usage of the limit reported for the check
+func (r Result) CurrentUsage() (Usage, bool) {
+	index := r.current()
+	if index < 0 || index >= len(r.Usages) {
+		return Usage{}, false
+	}
+
+	return r.Usages[index], true
+}
+
+// current : Index of the limit reported for the check, -1 without limits
+func (r Result) current() int {
+	if len(r.Limits) <= 0 {
+		return -1
+	}
+
+	if r.Exhausted >= 0 {
+		return r.Exhausted
+	}
+
+	index := 0
+	for other := range r.Usages {
+		if r.Usages[other].Remaining < r.Usages[index].Remaining {
+			index = other
+		}
+	}
+
+	return index
+}
+
+// SetClock : Replace the source of the current time used by Check,
+// nil restores the system clock
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	clock = now
+}
+
 // GetConfig : CRUD
 func GetConfig(uid string) (models.Config, error) {
+	return GetDomainConfig(DefaultDomain, uid)
+}
+
+// CreateOrUpdateConfig : CRUD
+func CreateOrUpdateConfig(uid string, config models.Config) error {
+	return CreateOrUpdateDomainConfig(DefaultDomain, uid, config)
+}
+
+// DeleteConfig : CRUD
+func DeleteConfig(uid string) error {
+	return DeleteDomainConfig(DefaultDomain, uid)
+}
+
+// GetDomainConfig : CRUD
+func GetDomainConfig(domain string, uid string) (models.Config, error) {
 	// Get config
 	var config models.Config
-	raw, err := redis.Client().Get(redisContext, uid).Result()
+	raw, err := redis.Client().Get(redisContext, configKey(domain, uid)).Result()
 	json.Unmarshal([]byte(raw), &config)
 
 	return config, err
 }
 
-// CreateOrUpdateConfig : CRUD
-func CreateOrUpdateConfig(uid string, config models.Config) error {
+// GetStoredConfig : Config of a domain along with its origin
+func GetStoredConfig(domain string, uid string) (models.StoredConfig, error) {
+	return readStoredConfig(configKey(domain, uid))
+}
+
+// CreateOrUpdateDomainConfig : CRUD
+func CreateOrUpdateDomainConfig(domain string, uid string, config models.Config) error {
 	// Set config
 	raw, _ := json.Marshal(config)
-	_, err := redis.Client().Set(redisContext, uid, raw, 0).Result()
+	_, err := redis.Client().Set(redisContext, configKey(domain, uid), raw, 0).Result()
+	invalidateConfig(configKey(domain, uid))
 
 	return err
 }
 
-// DeleteConfig : CRUD
-func DeleteConfig(uid string) error {
+// DeleteDomainConfig : CRUD
+func DeleteDomainConfig(domain string, uid string) error {
 	// Delete config
-	_, err := redis.Client().Del(redisContext, uid).Result()
+	_, err := redis.Client().Del(redisContext, configKey(domain, uid)).Result()
+	invalidateConfig(configKey(domain, uid))
+
+	// Ask the policy service again
+	forgetUnknownUID(uid)
 
 	return err
 }
 
-// FetchConfig : If config cannot be found locally, fallback
-// to the policy service hook
-func FetchConfig(uid string) (models.Config, error) {
-	// Get config
-	var config models.Config
+// Check : Check if current request is within the config
+func Check(uid string) (bool, error) {
+	result, err := Evaluate(uid, 1)
 
-	// Check endpoint definition
-	if len(helper.GetConfiguration().PolicyServiceEndpoint) <= 0 {
-		return config, errors.New("Policy Service Endpoint not defined")
-	}
+	return result.OK, err
+}
 
-	// Get request timeout
-	timeout := defaultTimeout
-	if len(helper.GetConfiguration().PolicyServiceTimeout) > 0 {
-		timeout, _ = strconv.Atoi(helper.GetConfiguration().PolicyServiceTimeout)
-	}
+// Evaluate : Check if current request, costing the given number of
+// hits, is within the config and return the limits it was checked against
+func Evaluate(uid string, hits int) (Result, error) {
+	return EvaluateDescriptor(DefaultDomain, []models.Entry{{Key: uidEntryKey, Value: uid}}, hits)
+}
 
-	// Create context
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
-	defer cancel()
+// loadConfig : Get a config, the one of the domain first then the one of
+// the default domain, falling back to the policy service hook and caching
+// the config it returns, then to the default plan. Configs are read from the
+// in-process cache when read recently. A stale fetched config is still used
+// while it is fetched again. The config is applied on top of the plan it
+// references. The counters stay in the domain of the request whichever
+// domain the config comes from. Errors are ErrNoConfig when no config
+// applies, or match ErrUnavailable when Redis or the policy service cannot
+// be reached.
+func loadConfig(domain string, uid string) (models.Config, error) {
+	stored, err := loadStoredConfig(configKey(domain, uid))
 
-	// Add endpoint URL
-	req, _ := http.NewRequestWithContext(ctx, "GET", helper.GetConfiguration().PolicyServiceEndpoint, nil)
+	// Check err
+	if redis.IsNil(err) && namespace(domain) != "" {
+		stored, err = loadStoredConfig(configKey(DefaultDomain, uid))
+	}
 
-	// Add authorization header if specified
-	if authorization := helper.GetConfiguration().PolicyServiceAuthorization; len(authorization) > 0 {
-		req.Header.Add("Authorization", authorization)
+	if err != nil && !redis.IsNil(err) {
+		return stored.Config, unavailable(err)
 	}
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	config := stored.Config
 
-	if err != nil {
-		return config, err
+	// Refresh stale fetched config
+	if err == nil && isStale(stored) {
+		go refreshConfig(uid)
 	}
 
-	// Read and parse data
-	body, _ := ioutil.ReadAll(resp.Body)
-	_ = json.Unmarshal([]byte(body), &config)
-	_ = resp.Body.Close()
+	// Check err
+	if err != nil {
+		// Try to fallback on the policy service endpoint, once
+		var fetched models.Config
+		fetched, err = fetchConfig(uid, false)
 
-	return config, err
-}
+		if errors.Is(err, ErrUnavailable) {
+			go refreshConfig(uid)
+		}
 
-// Check : Check if current request is within the config
-func Check(uid string) (bool, error) {
-	config, err := GetConfig(uid)
+		if err == nil {
+			// Cache config
+			config = fetched
+			go cacheFetchedConfig(uid, config)
+		} else if defaultConfig, ok := defaultPlanConfig(); ok {
+			config, err = defaultConfig, nil
+		}
 
-	// Check err
-	if err != nil {
-		// Try to fallback on the policy service endpoint
-		config, err = FetchConfig(uid)
+		if errors.Is(err, ErrUnavailable) {
+			return config, err
+		}
 
 		if err != nil {
-			return false, err
+			log.Debug("Config not found ", err)
+			return config, ErrNoConfig
 		}
+	}
+
+	// Apply plan
+	config, err = resolvePlan(config)
+	if errors.Is(err, ErrUnavailable) {
+		return config, err
+	}
 
-		// Cache config
-		go CreateOrUpdateConfig(uid, config)
+	if err != nil {
+		log.Error("Config of ", uid, " cannot be applied on its plan ", err)
+		return config, ErrNoConfig
 	}
 
 	// Check config
 	log.Debug("Config is ", config)
 
-	// Check if enabled
-	if !config.Enabled {
-		return false, nil
-	}
+	return config, nil
+}
 
-	// Create if needed
-	if len(config.Log) <= 0 {
-		config.Log = make(map[string]int)
-	}
+// evaluateLimits : Charge every limit of a subject atomically, all or nothing
+func evaluateLimits(domain string, subject string, limits []models.Limit, chargeType models.ChargeType, hits int) (Result, error) {
+	result := Result{Limits: limits, Exhausted: -1}
 
 	// Current time
-	currentTime := time.Now()
-
-	// Save config
-	defer CreateOrUpdateConfig(uid, config)
-
-	// Rate / s
-	// Get current time
-	withinRate := true
-	now := strconv.FormatInt(currentTime.Unix(), 10)
-	if val, ok := config.Log[now]; ok {
-		// Check rate
-		if val >= config.Rate {
-			withinRate = false
-		} else {
-			config.Log[now] = val + 1
-		}
-	} else {
-		config.Log[now] = 1
-	}
+	currentTime := clock()
 
-	if !withinRate {
-		return false, nil
+	counters := make([]counter, 0, len(limits))
+	for _, limit := range limits {
+		counters = append(counters, limitCounter(domain, subject, limit, currentTime))
 	}
 
-	// Quota / Interval
-	// Get interval type
-	withinQuota := true
-	var interval string
-	switch config.Quota.Interval {
-	case models.DayType:
-		interval = fmt.Sprintf(dayLayout,
-			currentTime.Year(), currentTime.Month(), currentTime.Day())
-	default: // Default is month
-		interval = fmt.Sprintf(monthLayout,
-			currentTime.Year(), currentTime.Month())
-	}
+	over, usages, err := charge(counters, creditKey(domain, subject), chargeType, hits, currentTime)
 
-	if val, ok := config.Log[interval]; ok {
-		// Check quota
-		if val >= config.Quota.Number {
-			withinQuota = false
-		} else {
-			config.Log[interval] = val + 1
-		}
-	} else {
-		config.Log[interval] = 1
+	if err != nil {
+		return result, unavailable(err)
 	}
 
-	checkStatus := withinRate && withinQuota
-	log.Debug("Answer is ", checkStatus)
+	result.Usages = usages
+
+	result.OK = over == 0
+	result.Exhausted = over - 1
+	log.Debug("Answer is ", result.OK)
+
+	if !result.OK {
+		log.Debug("Exhausted limit is ", limits[result.Exhausted].Name)
+	}
 
-	return checkStatus, nil
+	return result, nil
 }
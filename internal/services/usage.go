@@ -0,0 +1,119 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : usage.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/models"
+
+	"github.com/bit-broker/rate-service/pkg/redis"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+// ErrNoConfig : No config applies to the uid
+var ErrNoConfig = errors.New("No config")
+
+// ------------------------ GLOBAL -------------------- //
+
+// GetUsage : Current state of every limit of a uid, read from the counters
+// charged by Check without charging them. The config is looked up as Check
+// does, the descriptor trees of the uid are not reported.
+func GetUsage(domain string, uid string) (models.Usage, error) {
+	usage := models.Usage{UID: uid}
+
+	config, err := loadConfig(domain, uid)
+	if err != nil {
+		return usage, err
+	}
+
+	return configUsage(domain, uid, config, clock())
+}
+
+// configUsage : Current state of every limit of the config of a uid,
+// the config being applied on its plan and the domain the one of
+// its counters
+func configUsage(domain string, uid string, config models.Config, currentTime time.Time) (models.Usage, error) {
+	usage := models.Usage{UID: uid, Enabled: config.Enabled}
+
+	configLimits := limits(config)
+	counters := make([]counter, 0, len(configLimits))
+	for _, limit := range configLimits {
+		counters = append(counters, limitCounter(domain, uid, limit, currentTime))
+	}
+
+	_, usages, err := charge(counters, creditKey(domain, uid), peekCharge, 0, currentTime)
+	if err != nil {
+		return usage, err
+	}
+
+	// Credit left on top of the quotas
+	credit, err := redis.Client().Get(redisContext, creditKey(domain, uid)).Int()
+	if err != nil && !redis.IsNil(err) {
+		return usage, err
+	}
+	usage.Credit = credit
+
+	usage.Limits = make([]models.LimitUsage, 0, len(configLimits))
+	for index, limit := range configLimits {
+		usage.Limits = append(usage.Limits, limitUsage(limit, counters[index], usages[index], currentTime))
+	}
+
+	return usage, nil
+}
+
+// limitUsage : State of a limit from the usage of its counter, the hits
+// used being counted against the burst of a token bucket
+func limitUsage(limit models.Limit, c counter, usage Usage, t time.Time) models.LimitUsage {
+	state := models.LimitUsage{
+		Name:      limit.Name,
+		Algorithm: c.algorithm,
+		Number:    limit.Number,
+		Window:    models.Duration(limitWindow(limit)),
+		Remaining: usage.Remaining,
+		ResetAt:   t.Add(usage.Reset),
+	}
+
+	if len(state.Algorithm) <= 0 {
+		state.Algorithm = models.FixedWindowType
+	}
+
+	if used := counterCapacity(c) - usage.Remaining; used > 0 {
+		state.Used = used
+	}
+
+	// Only fixed windows have bounds, the others roll
+	if state.Algorithm == models.FixedWindowType {
+		var start, end time.Time
+		if len(limit.Interval) > 0 && limit.Interval != models.DurationType {
+			_, start, end = intervalPeriod(limit, t)
+		} else {
+			_, end = windowBucket(c.window, t)
+			start = end.Add(-c.window)
+		}
+		state.WindowStart, state.WindowEnd = &start, &end
+	}
+
+	return state
+}
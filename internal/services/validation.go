@@ -0,0 +1,230 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : validation.go
+ * Creation Date : 18-10-2026
+ */
+
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+)
+
+// violations : Invalid fields found so far
+type violations []helper.Violation
+
+// add : Add the violation of a field
+func (v *violations) add(field string, format string, args ...interface{}) {
+	*v = append(*v, helper.Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err : Every violation as an error, nil without any
+func (v violations) err() error {
+	if len(v) <= 0 {
+		return nil
+	}
+
+	return &helper.ValidationError{Violations: v}
+}
+
+// ValidUID : Whether a uid is an integer, as the uids of the REST API are
+func ValidUID(uid string) bool {
+	_, err := strconv.ParseUint(uid, 10, 64)
+
+	return err == nil
+}
+
+// ValidateConfig : Check a config before it is stored,
+// returning every invalid field at once
+func ValidateConfig(config models.Config) error {
+	var v violations
+	validateConfig(&v, config)
+
+	return v.err()
+}
+
+// validateConfig : Check every field of a config
+func validateConfig(v *violations, config models.Config) {
+	if config.Rate < 0 {
+		v.add("rate", "must not be negative")
+	}
+
+	validateUnit(v, "rate_unit", config.RateUnit)
+
+	if config.RateWindow < 0 {
+		v.add("rate_window", "must not be negative")
+	}
+
+	validateAlgorithm(v, "algorithm", config.Algorithm)
+
+	if config.Burst < 0 {
+		v.add("burst", "must not be negative")
+	}
+
+	validateQuota(v, config.Quota)
+
+	// Without limits array nor plan both shorthands always apply
+	if config.Enabled && len(config.Limits) <= 0 && len(config.Plan) <= 0 {
+		if config.Rate == 0 {
+			v.add("rate", "must be positive without limits nor plan")
+		}
+		if config.Quota == (models.Quota{}) {
+			v.add("quota.max_number", "must be positive without limits nor plan")
+		}
+	}
+
+	switch config.Charge {
+	case "", models.OnAdmitCharge, models.AllAttemptsCharge:
+	default:
+		v.add("charge", "unknown charge type %q", config.Charge)
+	}
+
+	if len(config.Plan) > 0 {
+		if _, err := GetPlan(config.Plan); err != nil {
+			v.add("plan", "unknown plan %q", config.Plan)
+		}
+	}
+
+	validateLimits(v, "limits", config.Limits)
+	validateDescriptors(v, "descriptors", config.Descriptors)
+}
+
+// validateQuota : Check a quota, which is either
+// empty or comes with its number of hits
+func validateQuota(v *violations, quota models.Quota) {
+	switch {
+	case quota.Number < 0:
+		v.add("quota.max_number", "must not be negative")
+	case quota.Number == 0 && quota != (models.Quota{}):
+		v.add("quota.max_number", "must be positive")
+	}
+
+	validateInterval(v, "quota", quota.Interval, "duration", quota.Duration)
+	validatePeriod(v, "quota", quota.Timezone, quota.Anchor)
+}
+
+// ValidateDescriptors : Check a descriptor tree before it is stored
+func ValidateDescriptors(descriptors []models.Descriptor) error {
+	var v violations
+	validateDescriptors(&v, "descriptors", descriptors)
+
+	return v.err()
+}
+
+// validateDescriptors : Check every node of a descriptor tree
+func validateDescriptors(v *violations, path string, descriptors []models.Descriptor) {
+	for index, descriptor := range descriptors {
+		node := fmt.Sprintf("%s[%d]", path, index)
+		if len(descriptor.Key) <= 0 {
+			v.add(node+".key", "is required")
+		}
+
+		validateLimits(v, node+".limits", descriptor.Limits)
+		validateDescriptors(v, node+".descriptors", descriptor.Descriptors)
+	}
+}
+
+// validateLimits : Check a limits array, the names of which
+// must be unique and not clash with the counters keys
+func validateLimits(v *violations, path string, limits []models.Limit) {
+	names := make(map[string]bool, len(limits))
+	for index, limit := range namedLimits(limits) {
+		node := fmt.Sprintf("%s[%d]", path, index)
+		if limit.Number <= 0 {
+			v.add(node+".max_number", "must be positive")
+		}
+
+		switch {
+		case limit.Name == rateLimitName, limit.Name == quotaLimitName, limit.Name == creditName:
+			v.add(node+".name", "%q is reserved", limit.Name)
+		case strings.Contains(limit.Name, ":"):
+			v.add(node+".name", "must not contain %q", ":")
+		case names[limit.Name]:
+			v.add(node+".name", "duplicate name %q", limit.Name)
+		}
+		names[limit.Name] = true
+
+		validateLimit(v, node, limit)
+	}
+}
+
+// validateLimit : Check the fields of a limit but its number
+func validateLimit(v *violations, path string, limit models.Limit) {
+	validateUnit(v, path+".unit", limit.Unit)
+
+	if limit.Window < 0 {
+		v.add(path+".window", "must not be negative")
+	}
+
+	validateInterval(v, path, limit.Interval, "window", limit.Window)
+	validatePeriod(v, path, limit.Timezone, limit.Anchor)
+	validateAlgorithm(v, path+".algorithm", limit.Algorithm)
+
+	if limit.Burst < 0 {
+		v.add(path+".burst", "must not be negative")
+	}
+}
+
+// validateUnit : Check a rate unit
+func validateUnit(v *violations, field string, unit models.UnitType) {
+	switch unit {
+	case "", models.SecondUnit, models.MinuteUnit, models.HourUnit:
+	default:
+		v.add(field, "unknown unit %q", unit)
+	}
+}
+
+// validateAlgorithm : Check a rate algorithm
+func validateAlgorithm(v *violations, field string, algorithm models.AlgorithmType) {
+	switch algorithm {
+	case "", models.FixedWindowType, models.SlidingWindowLogType, models.SlidingWindowCounterType, models.TokenBucketType:
+	default:
+		v.add(field, "unknown algorithm %q", algorithm)
+	}
+}
+
+// validateInterval : Check an interval type, rolling
+// durations must come with their length
+func validateInterval(v *violations, path string, interval models.IntervalType, durationField string, duration models.Duration) {
+	switch interval {
+	case "", models.HourType, models.DayType, models.WeekType, models.MonthType, models.YearType:
+	case models.DurationType:
+		if time.Duration(duration) < time.Millisecond {
+			v.add(path+"."+durationField, "is required by the interval type %q", interval)
+		}
+	default:
+		v.add(path+".interval_type", "unknown interval type %q", interval)
+	}
+}
+
+// validatePeriod : Check the time zone and the anchor of an interval
+func validatePeriod(v *violations, path string, timezone string, anchor string) {
+	if _, err := location(timezone); err != nil {
+		v.add(path+".timezone", "unknown timezone %q", timezone)
+	}
+
+	if _, ok := anchorDate(anchor); len(anchor) > 0 && !ok {
+		v.add(path+".anchor", "%q is not a date (%s)", anchor, anchorLayout)
+	}
+}
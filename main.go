@@ -32,9 +32,11 @@ import (
 	"github.com/bit-broker/rate-service/internal/controllers"
 	"github.com/bit-broker/rate-service/internal/helper"
 	"github.com/bit-broker/rate-service/internal/routes"
+	"github.com/bit-broker/rate-service/internal/services"
 	"github.com/bit-broker/rate-service/pkg/log"
 
 	ratelimit_v2 "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v2"
+	ratelimit_v3 "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v3"
 
 	"google.golang.org/grpc"
 )
@@ -50,6 +52,16 @@ func main() {
 	// Configure log level
 	log.SetLogLevel(config.LogLevel)
 
+	// Move configs stored under their bare uid
+	if err := services.MigrateConfigKeys(); err != nil {
+		log.Error("Config keys migration failed ", err)
+	}
+
+	// Clean configs stored with a legacy log
+	if err := services.MigrateLegacyLogs(); err != nil {
+		log.Error("Legacy log migration failed ", err)
+	}
+
 	// Setup cors for dev
 	options := cors.Options{
 		AllowedOrigins:   []string{"*"},
@@ -86,8 +98,9 @@ func startGRPCServer(host string) {
 	}
 	gRPCServer := grpc.NewServer()
 
-	// Register the service
+	// Register the service, both versions on the same listener
 	ratelimit_v2.RegisterRateLimitServiceServer(gRPCServer, controllers.RatelimitService{})
+	ratelimit_v3.RegisterRateLimitServiceServer(gRPCServer, controllers.RatelimitServiceV3{})
 	log.Info("Starting gRPC Server")
 	if err := gRPCServer.Serve(listner); err != nil {
 		log.Fatal("gRPC failed", err)
@@ -23,11 +23,13 @@
 package redis
 
 import (
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/bit-broker/rate-service/internal/helper"
 
-	"github.com/alicebob/miniredis"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -35,6 +37,9 @@ import (
 
 var redisClient *redis.Client = nil
 
+// Pipeliner : Commands sent to Redis at once, in a transaction or not
+type Pipeliner = redis.Pipeliner
+
 // ------------------------ GLOBAL -------------------- //
 
 // Client : This is a helper function to connect to Redis
@@ -74,6 +79,49 @@ func Client() *redis.Client {
 	return redisClient
 }
 
+// Script : This is a helper function to prepare a Lua script
+// to be run atomically against Redis
+func Script(src string) *redis.Script {
+	return redis.NewScript(src)
+}
+
+// IsNil : This is a helper function to check if an
+// error is due to a missing key
+func IsNil(err error) bool {
+	return err == redis.Nil
+}
+
+// Subscribe : This is a helper function to receive the messages of a
+// channel for good. Messages may be lost while disconnected, so reset
+// is called on every (re)subscription and every error, before waiting
+// for the retry delay.
+func Subscribe(ctx context.Context, channel string, retry time.Duration, onMessage func(payload string), onReset func(err error)) {
+	pubsub := Client().Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			onReset(err)
+
+			// Check cancelled
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			onReset(nil)
+		case *redis.Message:
+			onMessage(msg.Payload)
+		}
+	}
+}
+
 func mockRedis() *miniredis.Miniredis {
 	s, err := miniredis.Run()
 
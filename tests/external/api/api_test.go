@@ -24,17 +24,26 @@ package tests
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strconv"
 	"testing"
 
+	"github.com/bit-broker/rate-service/internal/controllers"
 	"github.com/bit-broker/rate-service/internal/helper"
 	"github.com/bit-broker/rate-service/internal/models"
 	"github.com/bit-broker/rate-service/internal/routes"
 
+	ratelimitcommon "github.com/datawire/ambassador/pkg/api/envoy/api/v2/ratelimit"
+	ratelimit "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v2"
+
 	"github.com/gorilla/mux"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -45,9 +54,60 @@ import (
 var uid = strconv.Itoa(rand.Intn(100))
 var mockupFirstConfig = `{"enabled":true,"quota":{"max_number":10,"interval_type":"month"},"rate":1}`
 var mockupSecondConfig = `{"enabled":true,"quota":{"max_number":15,"interval_type":"day"},"rate":2}`
+var mockupInvalidConfig = `{"enabled":true,"quota":{"max_number":15,"interval_type":"fortnight"},"rate":2}`
+var mockupInvalidTimezoneConfig = `{"enabled":true,"quota":{"max_number":15,"interval_type":"month","timezone":"Mars/Olympus"},"rate":2}`
+var mockupQuotalessConfig = `{"enabled":true,"rate":5}`
+var mockupCosts = `{"export":5}`
+var mockupInvalidCosts = `{"export":0}`
+var mockupDescriptors = `[{"key":"remote_address","limits":[{"max_number":10}]}]`
+var mockupInvalidDescriptors = `[{"value":"/export","limits":[{"max_number":10}]}]`
+var domainUID = strconv.Itoa(100 + rand.Intn(100))
+var mockupDomainConfig = `{"enabled":true,"quota":{"max_number":20,"interval_type":"month"},"rate":4}`
+var mockupStoredDomainConfig = `{"enabled":true,"quota":{"max_number":20,"interval_type":"month"},"rate":4,"source":"local"}`
+var mockupPolicy = `{"missing_uid":"default_limit","error":"fail_open","default_limit":{"max_number":10,"unit":"minute"}}`
+var mockupInvalidPolicy = `{"missing_uid":"fail_sometimes","default_limit":{"max_number":10}}`
+var mockupPlan = `{"enabled":false,"quota":{"max_number":1000,"interval_type":"month"},"rate":10}`
+var mockupPlanConfig = `{"enabled":true,"quota":{},"plan":"pro"}`
+var mockupUnknownPlanConfig = `{"enabled":true,"plan":"enterprise"}`
+var mockupHookSecret = "secret"
+var mockupPolicyEvent = `{"id":"event-1","changed":["` + uid + `"],"revoked":["unknown"]}`
+var mockupConflictingPolicyEvent = `{"id":"event-1","revoked":["` + uid + `"]}`
+var mockupInvalidPolicyEvent = `{"changed":["` + uid + `"]}`
+var mockupMalformedConfig = `{"enabled":true,"rate":`
+var mockupUnknownFieldConfig = `{"enabled":true,"rate":2,"quota":{"max_number":15,"interval_type":"day"},"rates":5}`
+var mockupViolatingConfig = `{"enabled":true,"rate":-1,"quota":{"interval_type":"day"},"limits":[{"max_number":0,"unit":"day"}]}`
+var mockupViolations = `{"status":422,"code":"validation_failed","message":"Validation failed","violations":[
+	{"field":"rate","message":"must not be negative"},
+	{"field":"quota.max_number","message":"must be positive"},
+	{"field":"limits[0].max_number","message":"must be positive"},
+	{"field":"limits[0].unit","message":"unknown unit \"day\""}]}`
+var usageUID = strconv.Itoa(200 + rand.Intn(100))
+var envoyUID = strconv.Itoa(400 + rand.Intn(100))
+var patchUID = strconv.Itoa(300 + rand.Intn(100))
+var mockupPatchConfig = `{"rate":3,"rate_unit":"minute","quota":{"max_number":12}}`
+var mockupPatchedConfig = `{"enabled":true,"quota":{"max_number":12,"interval_type":"month"},"rate":3,"rate_unit":"minute"}`
+var mockupRemovingPatchConfig = `{"rate_unit":null,"quota":{"interval_type":null}}`
+var mockupViolatingPatchConfig = `{"rate":-1,"quota":{"max_number":0,"interval_type":"fortnight"}}`
+var mockupUnknownFieldPatchConfig = `{"rates":3}`
+var mockupBulkConfigs = `{"uid":"1","enabled":true,"quota":{"max_number":10,"interval_type":"month"},"rate":1}
+{"uid":"2","enabled":false,"rate":2}
+`
+var mockupInvalidBulkConfigs = `[{"uid":"1","enabled":true,"rate":1,"quota":{"max_number":10}},{"uid":"one","enabled":true,"rate":-1}]`
+var mockupUnknownFieldBulkConfigs = `{"uid":"1","enabled":true,"rates":1}`
+var mockupUsageConfig = `{"enabled":true,"quota":{"max_number":20,"interval_type":"month"},"rate":4,"rate_unit":"minute"}`
+var mockupWindowConfig = `{"enabled":true,"quota":{"max_number":15,"interval_type":"day"},"rate":2,"rate_unit":"minute","rate_window":"1m30s"}`
+var mockupStoredWindowConfig = `{"enabled":true,"quota":{"max_number":15,"interval_type":"day"},"rate":2,"rate_unit":"minute","rate_window":"1m30s","source":"local"}`
 
 // ------------------------ GLOBAL -------------------- //
 
+// sign : Signature of a body with a secret
+func sign(body string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
 // TestAPI : API Test cases
 func TestAPI(t *testing.T) {
 	// Load env
@@ -177,6 +237,93 @@ var _ = Describe("API", func() {
 
 		})
 
+		It("should reject an unknown interval type", func() {
+			// Create request
+			var jsonData = []byte(mockupInvalidConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+uid+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should reject an unknown time zone", func() {
+			// Create request
+			var jsonData = []byte(mockupInvalidTimezoneConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+uid+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should reject a config without quota nor limits", func() {
+			// Create request
+			var jsonData = []byte(mockupQuotalessConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+uid+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Violations).To(Equal([]helper.Violation{
+				{Field: "quota.max_number", Message: "must be positive without limits nor plan"},
+			}))
+		})
+
+		It("should set a config with a rate window", func() {
+			// Create request
+			var jsonData = []byte(mockupWindowConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+uid+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupWindowConfig))
+		})
+
+		It("should find the rate window", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/"+uid+"/config", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupStoredWindowConfig))
+		})
+
 		It("should delete the config", func() {
 			// Create request
 			req, err := http.NewRequest("DELETE", "/api/v1/"+uid+"/config", nil)
@@ -207,4 +354,927 @@ var _ = Describe("API", func() {
 			Expect(rr.Code).To(Equal(http.StatusNotFound))
 		})
 	})
+	Context("Cost Routes", func() {
+		It("should set the costs", func() {
+			// Create request
+			var jsonData = []byte(mockupCosts)
+			req, err := http.NewRequest("PUT", "/api/v1/costs", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should find the costs", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/costs", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupCosts))
+		})
+
+		It("should reject a cost which isn't positive", func() {
+			// Create request
+			var jsonData = []byte(mockupInvalidCosts)
+			req, err := http.NewRequest("PUT", "/api/v1/costs", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+	Context("Descriptor Routes", func() {
+		It("should set the descriptors", func() {
+			// Create request
+			var jsonData = []byte(mockupDescriptors)
+			req, err := http.NewRequest("PUT", "/api/v1/descriptors", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should find the descriptors", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/descriptors", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupDescriptors))
+		})
+
+		It("should reject a descriptor without key", func() {
+			// Create request
+			var jsonData = []byte(mockupInvalidDescriptors)
+			req, err := http.NewRequest("PUT", "/api/v1/descriptors", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+	})
+	Context("Domain Routes", func() {
+		It("should create the config of a domain", func() {
+			// Create request
+			var jsonData = []byte(mockupDomainConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/domains/staging/"+domainUID+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should find the config of the domain", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/domains/staging/"+domainUID+"/config", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupStoredDomainConfig))
+		})
+
+		It("shouldn't find the config in the default domain", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/"+domainUID+"/config", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should delete the config of the domain", func() {
+			// Create request
+			req, err := http.NewRequest("DELETE", "/api/v1/domains/staging/"+domainUID+"/config", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+	})
+	Context("Policy Routes", func() {
+		It("should set the policy of a domain", func() {
+			// Create request
+			var jsonData = []byte(mockupPolicy)
+			req, err := http.NewRequest("PUT", "/api/v1/domains/staging/policy", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should find the policy of the domain", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/domains/staging/policy", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupPolicy))
+		})
+
+		It("should reject an unknown policy type", func() {
+			// Create request
+			var jsonData = []byte(mockupInvalidPolicy)
+			req, err := http.NewRequest("PUT", "/api/v1/policy", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should delete the policy of the domain", func() {
+			// Create request
+			req, err := http.NewRequest("DELETE", "/api/v1/domains/staging/policy", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("shouldn't find the deleted policy", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/domains/staging/policy", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	Context("Plan Routes", func() {
+		It("should create a plan", func() {
+			// Create request
+			var jsonData = []byte(mockupPlan)
+			req, err := http.NewRequest("PUT", "/api/v1/plans/pro", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should find the plans", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/plans", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(`{"pro":` + mockupPlan + `}`))
+		})
+
+		It("should create a config referencing the plan", func() {
+			// Create request
+			var jsonData = []byte(mockupPlanConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+domainUID+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupPlanConfig))
+		})
+
+		It("should reject a config referencing an unknown plan", func() {
+			// Create request
+			var jsonData = []byte(mockupUnknownPlanConfig)
+			req, err := http.NewRequest("PUT", "/api/v1/"+domainUID+"/config", bytes.NewBuffer(jsonData))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should keep a plan in use", func() {
+			// Referenced by a config
+			req, err := http.NewRequest("DELETE", "/api/v1/plans/pro", nil)
+			Expect(err).To(BeNil())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.ConflictCode))
+
+			// Referenced as the default plan
+			req, err = http.NewRequest("DELETE", "/api/v1/"+domainUID+"/config", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			os.Setenv("DEFAULT_PLAN", "pro")
+			defer os.Unsetenv("DEFAULT_PLAN")
+
+			req, err = http.NewRequest("DELETE", "/api/v1/plans/pro", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("should delete the plan", func() {
+			// Create request
+			req, err := http.NewRequest("DELETE", "/api/v1/plans/pro", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("shouldn't find the deleted plan", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/plans/pro", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+	Context("Policy Hook Routes", func() {
+		// postEvent : Send an event with its signature
+		postEvent := func(body string, signature string) *httptest.ResponseRecorder {
+			// Create request
+			req, err := http.NewRequest("POST", "/api/v1/hooks/policy", bytes.NewBufferString(body))
+			Expect(err).To(BeNil())
+			req.Header.Set("X-Signature", signature)
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			return rr
+		}
+
+		BeforeEach(func() {
+			os.Setenv("POLICY_SERVICE_HOOK_SECRET", mockupHookSecret)
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("POLICY_SERVICE_HOOK_SECRET")
+		})
+
+		It("should reject the events without secret", func() {
+			os.Unsetenv("POLICY_SERVICE_HOOK_SECRET")
+
+			rr := postEvent(mockupPolicyEvent, sign(mockupPolicyEvent, ""))
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject the invalid signatures", func() {
+			rr := postEvent(mockupPolicyEvent, sign(mockupPolicyEvent, "other"))
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+
+			rr = postEvent(mockupPolicyEvent, "")
+			Expect(rr.Code).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("should reject the events without id", func() {
+			rr := postEvent(mockupInvalidPolicyEvent, sign(mockupInvalidPolicyEvent, mockupHookSecret))
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should apply the signed events", func() {
+			rr := postEvent(mockupPolicyEvent, sign(mockupPolicyEvent, mockupHookSecret))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Idempotent-Replayed")).To(BeEmpty())
+		})
+
+		It("should ignore the replays", func() {
+			rr := postEvent(mockupPolicyEvent, sign(mockupPolicyEvent, mockupHookSecret))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Idempotent-Replayed")).To(Equal("true"))
+		})
+
+		It("should reject another event with the same id", func() {
+			rr := postEvent(mockupConflictingPolicyEvent, sign(mockupConflictingPolicyEvent, mockupHookSecret))
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+		})
+	})
+	Context("Validation", func() {
+		// putConfig : Set the config of a uid
+		putConfig := func(uid string, body string) *httptest.ResponseRecorder {
+			// Create request
+			req, err := http.NewRequest("PUT", "/api/v1/"+uid+"/config", bytes.NewBufferString(body))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			return rr
+		}
+
+		It("should reject a malformed body", func() {
+			rr := putConfig(uid, mockupMalformedConfig)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.InvalidJSONCode))
+		})
+
+		It("should reject an empty body", func() {
+			rr := putConfig(uid, "")
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should reject the unknown fields", func() {
+			rr := putConfig(uid, mockupUnknownFieldConfig)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.UnknownFieldCode))
+			Expect(response.Violations).To(Equal([]helper.Violation{{Field: "rates", Message: "unknown field"}}))
+		})
+
+		It("should reject a uid which isn't an integer", func() {
+			rr := putConfig("user-1", mockupSecondConfig)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.InvalidUIDCode))
+		})
+
+		It("should return every violation", func() {
+			rr := putConfig(uid, mockupViolatingConfig)
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rr.Body.String()).To(MatchJSON(mockupViolations))
+		})
+
+		It("should accept a config as returned", func() {
+			rr := putConfig(uid, mockupStoredWindowConfig)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(mockupWindowConfig))
+		})
+	})
+	Context("Usage Routes", func() {
+		It("should report the usage of every limit", func() {
+			// Create config
+			req, err := http.NewRequest("PUT", "/api/v1/domains/staging/"+usageUID+"/config", bytes.NewBufferString(mockupUsageConfig))
+			Expect(err).To(BeNil())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Create request
+			req, err = http.NewRequest("GET", "/api/v1/domains/staging/"+usageUID+"/usage", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr = httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var usage models.Usage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+			Expect(usage.UID).To(Equal(usageUID))
+			Expect(usage.Limits).To(HaveLen(2))
+			Expect(usage.Limits[0].Name).To(Equal("rate"))
+			Expect(usage.Limits[0].Remaining).To(Equal(4))
+			Expect(usage.Limits[1].Name).To(Equal("quota"))
+			Expect(usage.Limits[1].Remaining).To(Equal(20))
+
+			// Counters are never exposed
+			Expect(rr.Body.String()).NotTo(ContainSubstring("counter:"))
+		})
+
+		It("shouldn't find the usage of a uid without config", func() {
+			// Without policy service to fetch it from
+			endpoint := os.Getenv("POLICY_SERVICE_ENDPOINT")
+			os.Setenv("POLICY_SERVICE_ENDPOINT", "")
+			defer os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/"+usageUID+"/usage", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should report the usage charged by Envoy under the domain of its requests", func() {
+			// serve : Perform a request on the router
+			serve := func(method string, path string, body string) *httptest.ResponseRecorder {
+				req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+				Expect(err).To(BeNil())
+
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				return rr
+			}
+
+			// remaining : Hits left of the rate limit of a usage route
+			remaining := func(path string) int {
+				rr := serve("GET", path, "")
+				Expect(rr.Code).To(Equal(http.StatusOK))
+
+				var usage models.Usage
+				Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+				return usage.Limits[0].Remaining
+			}
+
+			// Config of the default domain only
+			Expect(serve("PUT", "/api/v1/"+envoyUID+"/config", mockupUsageConfig).Code).To(Equal(http.StatusOK))
+
+			// Ambassador always sends its own domain
+			var service controllers.RatelimitService
+			response, err := service.ShouldRateLimit(context.Background(), &ratelimit.RateLimitRequest{
+				Domain: "ambassador",
+				Descriptors: []*ratelimitcommon.RateLimitDescriptor{{
+					Entries: []*ratelimitcommon.RateLimitDescriptor_Entry{{Key: "uid", Value: envoyUID}},
+				}},
+			})
+			Expect(err).To(BeNil())
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+
+			// The routes without domain act on the default domain only
+			Expect(remaining("/api/v1/" + envoyUID + "/usage")).To(Equal(4))
+			Expect(remaining("/api/v1/domains/ambassador/" + envoyUID + "/usage")).To(Equal(3))
+
+			Expect(serve("POST", "/api/v1/"+envoyUID+"/counters/reset", `{}`).Code).To(Equal(http.StatusOK))
+			Expect(remaining("/api/v1/domains/ambassador/" + envoyUID + "/usage")).To(Equal(3))
+
+			Expect(serve("POST", "/api/v1/domains/ambassador/"+envoyUID+"/counters/reset", `{}`).Code).To(Equal(http.StatusOK))
+			Expect(remaining("/api/v1/domains/ambassador/" + envoyUID + "/usage")).To(Equal(4))
+		})
+	})
+	Context("Counter Routes", func() {
+		// send : Send a request to the counters of the usage uid
+		send := func(method string, path string, body string) *httptest.ResponseRecorder {
+			// Create request
+			req, err := http.NewRequest(method, "/api/v1/domains/staging/"+usageUID+path, bytes.NewBufferString(body))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			return rr
+		}
+
+		It("should grant a credit", func() {
+			rr := send("POST", "/counters/credit", `{"hits":5,"actor":"support","reason":"goodwill"}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var usage models.Usage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+			Expect(usage.Credit).To(Equal(5))
+		})
+
+		It("should set a counter", func() {
+			rr := send("PUT", "/counters/quota", `{"used":12}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var usage models.Usage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+			Expect(usage.Limits[1].Used).To(Equal(12))
+		})
+
+		It("should reset the counters", func() {
+			rr := send("POST", "/counters/reset", `{"limits":["quota"]}`)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var usage models.Usage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+			Expect(usage.Limits[1].Used).To(Equal(0))
+		})
+
+		It("shouldn't find an unknown limit", func() {
+			rr := send("PUT", "/counters/monthly", `{"used":1}`)
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should reject a credit which isn't positive", func() {
+			rr := send("POST", "/counters/credit", `{"hits":0}`)
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+		})
+
+		It("should return the audit trail", func() {
+			rr := send("GET", "/audit", "")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var entries []models.AuditEntry
+			Expect(json.Unmarshal(rr.Body.Bytes(), &entries)).To(BeNil())
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].Action).To(Equal(models.ResetAction))
+			Expect(entries[2].Action).To(Equal(models.CreditAction))
+			Expect(entries[2].Actor).To(Equal("support"))
+		})
+	})
+	Context("Listing Routes", func() {
+		It("should list the configs of a domain", func() {
+			// Create config
+			req, err := http.NewRequest("PUT", "/api/v1/domains/catalog/"+uid+"/config", bytes.NewBufferString(mockupFirstConfig))
+			Expect(err).To(BeNil())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Create request
+			req, err = http.NewRequest("GET", "/api/v1/domains/catalog/configs?enabled=true&interval_type=month&limit=10&total=true", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr = httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var page models.ConfigPage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &page)).To(BeNil())
+			Expect(page.Total).NotTo(BeNil())
+			Expect(*page.Total).To(Equal(1))
+			Expect(page.NextCursor).To(BeEmpty())
+			Expect(page.Configs).To(HaveLen(1))
+			Expect(page.Configs[0].UID).To(Equal(uid))
+		})
+
+		It("should reject an invalid filter", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/configs?enabled=maybe&limit=0", nil)
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Violations).To(HaveLen(2))
+		})
+	})
+
+	Context("Bulk Routes", func() {
+		It("should import a stream of configs", func() {
+			// Create request
+			req, err := http.NewRequest("POST", "/api/v1/domains/bulk/configs:bulk", bytes.NewBufferString(mockupBulkConfigs))
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/x-ndjson")
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var report models.ImportReport
+			Expect(json.Unmarshal(rr.Body.Bytes(), &report)).To(BeNil())
+			Expect(report.Created).To(Equal(2))
+			Expect(report.Items).To(HaveLen(2))
+			Expect(report.Items[1].UID).To(Equal("2"))
+
+			// Check config
+			req, err = http.NewRequest("GET", "/api/v1/domains/bulk/2/config", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(MatchJSON(`{"enabled":false,"quota":{},"rate":2,"source":"local"}`))
+		})
+
+		It("should export the configs in the format imported", func() {
+			// Create request
+			req, err := http.NewRequest("GET", "/api/v1/domains/bulk/configs:export?counters=true", nil)
+			Expect(err).To(BeNil())
+			req.Header.Set("Accept", "application/x-ndjson")
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+			// Import the export again
+			req, err = http.NewRequest("POST", "/api/v1/domains/bulk/configs:bulk", bytes.NewReader(rr.Body.Bytes()))
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var report models.ImportReport
+			Expect(json.Unmarshal(rr.Body.Bytes(), &report)).To(BeNil())
+			Expect(report.Updated).To(Equal(2))
+
+			// Export as an array
+			req, err = http.NewRequest("GET", "/api/v1/domains/bulk/configs:export", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var items []models.ConfigItem
+			Expect(json.Unmarshal(rr.Body.Bytes(), &items)).To(BeNil())
+			Expect(items).To(HaveLen(2))
+		})
+
+		It("should reject an invalid import", func() {
+			// Create request
+			req, err := http.NewRequest("POST", "/api/v1/domains/bulk-invalid/configs:bulk", bytes.NewBufferString(mockupInvalidBulkConfigs))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Violations).To(Equal([]helper.Violation{
+				{Field: "[1].uid", Message: `must be an integer, got "one"`},
+				{Field: "[1].rate", Message: "must not be negative"},
+				{Field: "[1].quota.max_number", Message: "must be positive without limits nor plan"},
+			}))
+
+			// Nothing imported
+			req, err = http.NewRequest("GET", "/api/v1/domains/bulk-invalid/1/config", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+
+			// Unknown field of a stream
+			req, err = http.NewRequest("POST", "/api/v1/configs:bulk", bytes.NewBufferString(mockupUnknownFieldBulkConfigs))
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.UnknownFieldCode))
+		})
+	})
+
+	Context("Patch Routes", func() {
+		It("should patch a config, keeping its counters", func() {
+			// Create config
+			req, err := http.NewRequest("PUT", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(mockupFirstConfig))
+			Expect(err).To(BeNil())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Use some quota
+			req, err = http.NewRequest("PUT", "/api/v1/domains/patching/"+patchUID+"/counters/quota", bytes.NewBufferString(`{"used":4}`))
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Create request
+			req, err = http.NewRequest("PATCH", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(mockupPatchConfig))
+			Expect(err).To(BeNil())
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+
+			// Create recorder
+			rr = httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Check the response body
+			Expect(rr.Body.String()).To(MatchJSON(mockupPatchedConfig))
+
+			// Check counters
+			req, err = http.NewRequest("GET", "/api/v1/domains/patching/"+patchUID+"/usage", nil)
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			var usage models.Usage
+			Expect(json.Unmarshal(rr.Body.Bytes(), &usage)).To(BeNil())
+			Expect(usage.Limits[1].Name).To(Equal("quota"))
+			Expect(usage.Limits[1].Used).To(Equal(4))
+			Expect(usage.Limits[1].Remaining).To(Equal(8))
+		})
+
+		It("should remove the fields patched with null", func() {
+			// Create request
+			req, err := http.NewRequest("PATCH", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(mockupRemovingPatchConfig))
+			Expect(err).To(BeNil())
+
+			// Create recorder
+			rr := httptest.NewRecorder()
+
+			// Perform request
+			router.ServeHTTP(rr, req)
+
+			// Check the status code
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			// Check the response body
+			Expect(rr.Body.String()).To(MatchJSON(`{"enabled":true,"quota":{"max_number":12},"rate":3}`))
+		})
+
+		It("should reject an invalid patch", func() {
+			// Invalid patched config
+			req, err := http.NewRequest("PATCH", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(mockupViolatingPatchConfig))
+			Expect(err).To(BeNil())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusUnprocessableEntity))
+
+			var response helper.ErrorResponse
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Violations).To(Equal([]helper.Violation{
+				{Field: "rate", Message: "must not be negative"},
+				{Field: "quota.max_number", Message: "must be positive"},
+				{Field: "quota.interval_type", Message: `unknown interval type "fortnight"`},
+			}))
+
+			// Unknown field
+			req, err = http.NewRequest("PATCH", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(mockupUnknownFieldPatchConfig))
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			Expect(json.Unmarshal(rr.Body.Bytes(), &response)).To(BeNil())
+			Expect(response.Code).To(Equal(helper.UnknownFieldCode))
+
+			// Not an object
+			req, err = http.NewRequest("PATCH", "/api/v1/domains/patching/"+patchUID+"/config", bytes.NewBufferString(`[{"op":"remove","path":"/rate"}]`))
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+
+			// Unknown config
+			req, err = http.NewRequest("PATCH", "/api/v1/domains/patching/"+uid+"/config", bytes.NewBufferString(mockupPatchConfig))
+			Expect(err).To(BeNil())
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
 })
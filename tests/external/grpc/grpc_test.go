@@ -0,0 +1,425 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : grpc_test.go
+ * Creation Date : 18-10-2026
+ */
+
+package tests
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bit-broker/rate-service/internal/controllers"
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/redis"
+
+	ratelimitcommon "github.com/datawire/ambassador/pkg/api/envoy/api/v2/ratelimit"
+	ratelimitcommonv3 "github.com/datawire/ambassador/pkg/api/envoy/extensions/common/ratelimit/v3"
+	ratelimit "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v2"
+	ratelimitv3 "github.com/datawire/ambassador/pkg/api/envoy/service/ratelimit/v3"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// ------------------------ GLOBAL -------------------- //
+
+var uid = strconv.Itoa(rand.Intn(100))
+var sharedUID = strconv.Itoa(100 + rand.Intn(100))
+var headersUID = strconv.Itoa(200 + rand.Intn(100))
+var domainUID = strconv.Itoa(300 + rand.Intn(100))
+var unknownUID = strconv.Itoa(400 + rand.Intn(100))
+var outageUID = strconv.Itoa(500 + rand.Intn(100))
+var fallbackUID = strconv.Itoa(600 + rand.Intn(100))
+var mockupConfig = models.Config{Enabled: true, Rate: 2, Quota: models.Quota{Number: 100}, Descriptors: []models.Descriptor{
+	{Key: "path", Value: "/export", Limits: []models.Limit{{Name: "export", Number: 1, Unit: models.MinuteUnit}}},
+}}
+var mockupDescriptors = []models.Descriptor{
+	{Key: "remote_address", Limits: []models.Limit{{Name: "address", Number: 1}}},
+}
+var mockupStart = time.Date(2030, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+// ------------------------ GLOBAL -------------------- //
+
+// TestGRPC : gRPC Test cases
+func TestGRPC(t *testing.T) {
+	// Load env
+	helper.LoadEnv(helper.TestEnv)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "gRPC Test Suite")
+}
+
+// descriptor : Request descriptor from key value pairs
+func descriptor(pairs ...string) *ratelimitcommon.RateLimitDescriptor {
+	entries := make([]*ratelimitcommon.RateLimitDescriptor_Entry, 0, len(pairs)/2)
+	for index := 0; index+1 < len(pairs); index += 2 {
+		entries = append(entries, &ratelimitcommon.RateLimitDescriptor_Entry{Key: pairs[index], Value: pairs[index+1]})
+	}
+
+	return &ratelimitcommon.RateLimitDescriptor{Entries: entries}
+}
+
+// headers : Headers of a v2 response as a map
+func headers(response *ratelimit.RateLimitResponse) map[string]string {
+	values := make(map[string]string)
+	for _, header := range response.Headers {
+		values[header.Key] = header.Value
+	}
+
+	return values
+}
+
+// headersV3 : Headers of a v3 response as a map
+func headersV3(response *ratelimitv3.RateLimitResponse) map[string]string {
+	values := make(map[string]string)
+	for _, header := range response.ResponseHeadersToAdd {
+		values[header.Key] = header.Value
+	}
+
+	return values
+}
+
+// descriptorV3 : Request descriptor from key value pairs, v3
+func descriptorV3(pairs ...string) *ratelimitcommonv3.RateLimitDescriptor {
+	entries := make([]*ratelimitcommonv3.RateLimitDescriptor_Entry, 0, len(pairs)/2)
+	for index := 0; index+1 < len(pairs); index += 2 {
+		entries = append(entries, &ratelimitcommonv3.RateLimitDescriptor_Entry{Key: pairs[index], Value: pairs[index+1]})
+	}
+
+	return &ratelimitcommonv3.RateLimitDescriptor{Entries: entries}
+}
+
+var _ = Describe("gRPC", func() {
+	var service controllers.RatelimitService
+	var serviceV3 controllers.RatelimitServiceV3
+
+	// shouldRateLimitDomain : Send a request of a domain with the given descriptors
+	shouldRateLimitDomain := func(domain string, descriptors ...*ratelimitcommon.RateLimitDescriptor) *ratelimit.RateLimitResponse {
+		response, err := service.ShouldRateLimit(context.Background(), &ratelimit.RateLimitRequest{
+			Domain:      domain,
+			Descriptors: descriptors,
+		})
+		Expect(err).To(BeNil())
+
+		return response
+	}
+
+	// shouldRateLimit : Send a request with the given descriptors
+	shouldRateLimit := func(descriptors ...*ratelimitcommon.RateLimitDescriptor) *ratelimit.RateLimitResponse {
+		return shouldRateLimitDomain("test", descriptors...)
+	}
+
+	// shouldRateLimitV3 : Send a v3 request with the given descriptors
+	shouldRateLimitV3 := func(descriptors ...*ratelimitcommonv3.RateLimitDescriptor) *ratelimitv3.RateLimitResponse {
+		response, err := serviceV3.ShouldRateLimit(context.Background(), &ratelimitv3.RateLimitRequest{
+			Domain:      "test",
+			Descriptors: descriptors,
+		})
+		Expect(err).To(BeNil())
+
+		return response
+	}
+
+	BeforeEach(func() {
+		services.SetClock(func() time.Time { return mockupStart })
+	})
+
+	AfterEach(func() {
+		services.SetClock(nil)
+	})
+
+	Context("Descriptors", func() {
+		It("should reject requests without uid nor limits", func() {
+			response := shouldRateLimit(descriptor("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should return a status per descriptor", func() {
+			Expect(services.CreateOrUpdateConfig(uid, mockupConfig)).To(BeNil())
+
+			response := shouldRateLimit(descriptor("uid", uid), descriptor("uid", uid, "path", "/export"), descriptor("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses).To(HaveLen(3))
+			Expect(response.Statuses[0].CurrentLimit.Name).To(Equal("rate"))
+			Expect(response.Statuses[0].CurrentLimit.Unit).To(Equal(ratelimit.RateLimitResponse_RateLimit_SECOND))
+			Expect(response.Statuses[1].CurrentLimit.Name).To(Equal("export"))
+			Expect(response.Statuses[1].CurrentLimit.Unit).To(Equal(ratelimit.RateLimitResponse_RateLimit_MINUTE))
+			Expect(response.Statuses[2].Code).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses[2].CurrentLimit).To(BeNil())
+		})
+
+		It("should evaluate every descriptor on its own", func() {
+			response := shouldRateLimit(descriptor("uid", uid), descriptor("uid", uid, "path", "/export"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+			Expect(response.Statuses[0].Code).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses[1].Code).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should limit the global descriptors", func() {
+			Expect(services.SetDescriptors("test", mockupDescriptors)).To(BeNil())
+
+			response := shouldRateLimit(descriptor("remote_address", "10.0.0.1"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses[0].CurrentLimit.Name).To(Equal("address"))
+
+			response = shouldRateLimit(descriptor("remote_address", "10.0.0.1"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+	})
+	Context("Versions", func() {
+		It("should return the same statuses in v3", func() {
+			// Next window of the rate
+			services.SetClock(func() time.Time { return mockupStart.Add(time.Second) })
+
+			response := shouldRateLimitV3(descriptorV3("uid", uid, "path", "/import"), descriptorV3("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimitv3.RateLimitResponse_OK))
+			Expect(response.Statuses).To(HaveLen(2))
+			Expect(response.Statuses[0].CurrentLimit.Name).To(Equal("rate"))
+			Expect(response.Statuses[0].CurrentLimit.Unit).To(Equal(ratelimitv3.RateLimitResponse_RateLimit_SECOND))
+			Expect(response.Statuses[1].CurrentLimit).To(BeNil())
+			Expect(response.DynamicMetadata).To(BeNil())
+		})
+
+		It("should reject v3 requests without uid nor limits", func() {
+			response := shouldRateLimitV3(descriptorV3("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimitv3.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should share the counters of both versions", func() {
+			Expect(services.CreateOrUpdateConfig(sharedUID, models.Config{Enabled: true, Rate: 2, Quota: models.Quota{Number: 100}})).To(BeNil())
+
+			Expect(shouldRateLimit(descriptor("uid", sharedUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitV3(descriptorV3("uid", sharedUID)).OverallCode).To(Equal(ratelimitv3.RateLimitResponse_OK))
+			Expect(shouldRateLimit(descriptor("uid", sharedUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+
+			response := shouldRateLimitV3(descriptorV3("uid", sharedUID))
+			Expect(response.OverallCode).To(Equal(ratelimitv3.RateLimitResponse_OVER_LIMIT))
+			Expect(response.DynamicMetadata.AsMap()).To(Equal(map[string]interface{}{
+				"exhausted_limits": []interface{}{"rate"},
+			}))
+		})
+	})
+	Context("Headers", func() {
+		AfterEach(func() {
+			os.Unsetenv("RATE_LIMIT_HEADERS")
+		})
+
+		It("should return the remaining hits and the X-RateLimit headers", func() {
+			Expect(services.CreateOrUpdateConfig(headersUID, models.Config{Enabled: true, Rate: 2, RateUnit: models.MinuteUnit, Quota: models.Quota{Number: 100}})).To(BeNil())
+
+			response := shouldRateLimit(descriptor("uid", headersUID))
+			Expect(response.Statuses[0].LimitRemaining).To(Equal(uint32(1)))
+			Expect(headers(response)).To(Equal(map[string]string{
+				"X-RateLimit-Limit":     "2",
+				"X-RateLimit-Remaining": "1",
+				"X-RateLimit-Reset":     "60",
+			}))
+
+			response = shouldRateLimit(descriptor("uid", headersUID))
+			Expect(response.Statuses[0].LimitRemaining).To(Equal(uint32(0)))
+		})
+
+		It("should return the Retry-After header when over limit", func() {
+			response := shouldRateLimit(descriptor("uid", headersUID))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+			Expect(headers(response)).To(HaveKeyWithValue("Retry-After", "60"))
+		})
+
+		It("should return the IETF draft headers in v3", func() {
+			os.Setenv("RATE_LIMIT_HEADERS", "ratelimit")
+
+			response := shouldRateLimitV3(descriptorV3("uid", headersUID))
+			Expect(response.Statuses[0].DurationUntilReset.AsDuration()).To(Equal(time.Minute))
+			Expect(headersV3(response)).To(Equal(map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "60",
+			}))
+		})
+
+		It("should return no headers when disabled", func() {
+			os.Setenv("RATE_LIMIT_HEADERS", "none")
+
+			response := shouldRateLimit(descriptor("uid", headersUID))
+			Expect(response.Headers).To(BeEmpty())
+		})
+	})
+	Context("Domains", func() {
+		It("should use the config of the request domain", func() {
+			Expect(services.CreateOrUpdateConfig(domainUID, models.Config{Enabled: true, Rate: 2, Quota: models.Quota{Number: 100}})).To(BeNil())
+			Expect(services.CreateOrUpdateDomainConfig("staging", domainUID, models.Config{Enabled: true, Rate: 1, Quota: models.Quota{Number: 100}})).To(BeNil())
+
+			Expect(shouldRateLimitDomain("staging", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("staging", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+
+			// Other domains fall back to the config of the default one
+			Expect(shouldRateLimitDomain("", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("production", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("production", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("production", descriptor("uid", domainUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should keep the counters of every domain apart", func() {
+			services.SetClock(func() time.Time { return mockupStart })
+			defer services.SetClock(nil)
+
+			uid := fallbackUID
+			Expect(services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 1, Quota: models.Quota{Number: 100}})).To(BeNil())
+
+			// Each domain spends its own budget on the default config
+			Expect(shouldRateLimitDomain("production", descriptor("uid", uid)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("production", descriptor("uid", uid)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+			Expect(shouldRateLimitDomain("staging", descriptor("uid", uid)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("", descriptor("uid", uid)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+		})
+	})
+	Context("Policies", func() {
+		var policyService *httptest.Server
+		var endpoint, retries string
+
+		BeforeEach(func() {
+			// The policy service doesn't know the uids, and is down for one
+			policyService = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, "/"+outageUID) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+
+			endpoint, retries = os.Getenv("POLICY_SERVICE_ENDPOINT"), os.Getenv("POLICY_SERVICE_RETRIES")
+			os.Setenv("POLICY_SERVICE_ENDPOINT", policyService.URL+"/configs/{uid}")
+			os.Setenv("POLICY_SERVICE_RETRIES", "0")
+		})
+
+		AfterEach(func() {
+			os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+			os.Setenv("POLICY_SERVICE_RETRIES", retries)
+			policyService.Close()
+		})
+
+		It("should admit requests without uid when failing open", func() {
+			Expect(services.SetPolicy("open", models.Policy{MissingUID: models.FailOpenPolicy, Error: models.FailOpenPolicy})).To(BeNil())
+
+			response := shouldRateLimitDomain("open", descriptor("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses).To(HaveLen(1))
+		})
+
+		It("should drop a policy changed by another instance", func() {
+			Expect(services.SetPolicy("replicated", models.Policy{MissingUID: models.FailOpenPolicy})).To(BeNil())
+
+			response := shouldRateLimitDomain("replicated", descriptor("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+
+			// Written by another instance, the cached policy still applies
+			key := "domain:replicated:policy"
+			Expect(redis.Client().Set(context.TODO(), key, `{"missing_uid":"fail_closed"}`, 0).Err()).To(BeNil())
+			response = shouldRateLimitDomain("replicated", descriptor("user_agent", "curl"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+
+			// Until told by the other instance, once subscribed
+			Eventually(func() ratelimit.RateLimitResponse_Code {
+				Expect(redis.Client().Publish(context.TODO(), "invalidations", key).Err()).To(BeNil())
+				return shouldRateLimitDomain("replicated", descriptor("user_agent", "curl")).OverallCode
+			}).Should(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should admit requests which cannot be checked when failing open", func() {
+			// Policy service down
+			response := shouldRateLimitDomain("open", descriptor("uid", outageUID))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+		})
+
+		It("should reject requests which cannot be checked when failing closed", func() {
+			response := shouldRateLimitDomain("closed", descriptor("uid", outageUID))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should return a gRPC error when failing closed with an error", func() {
+			Expect(services.SetPolicy("unavailable", models.Policy{GRPCError: true})).To(BeNil())
+
+			_, err := service.ShouldRateLimit(context.Background(), &ratelimit.RateLimitRequest{
+				Domain:      "unavailable",
+				Descriptors: []*ratelimitcommon.RateLimitDescriptor{descriptor("uid", outageUID)},
+			})
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+
+			_, err = serviceV3.ShouldRateLimit(context.Background(), &ratelimitv3.RateLimitRequest{
+				Domain:      "unavailable",
+				Descriptors: []*ratelimitcommonv3.RateLimitDescriptor{descriptorV3("uid", outageUID)},
+			})
+			Expect(status.Code(err)).To(Equal(codes.Unavailable))
+		})
+
+		It("should apply the default limit to requests without uid", func() {
+			Expect(services.SetPolicy("anonymous", models.Policy{
+				MissingUID:   models.DefaultLimitPolicy,
+				DefaultLimit: models.Limit{Number: 2, Unit: models.MinuteUnit},
+			})).To(BeNil())
+
+			response := shouldRateLimitDomain("anonymous", descriptor("user_agent", "curl"), descriptor("user_agent", "wget"))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses[1].CurrentLimit.Name).To(Equal("default"))
+
+			// Shared by every request without uid
+			Expect(shouldRateLimitDomain("anonymous", descriptor("user_agent", "wget")).OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(shouldRateLimitDomain("anonymous", descriptor("user_agent", "curl")).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+
+		It("should apply the default limit in process to requests which cannot be checked", func() {
+			Expect(services.SetPolicy("degraded", models.Policy{
+				Error:        models.DefaultLimitPolicy,
+				DefaultLimit: models.Limit{Number: 1, Unit: models.MinuteUnit},
+			})).To(BeNil())
+
+			response := shouldRateLimitDomain("degraded", descriptor("uid", outageUID))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OK))
+			Expect(response.Statuses[0].LimitRemaining).To(Equal(uint32(0)))
+
+			response = shouldRateLimitDomain("degraded", descriptor("uid", outageUID))
+			Expect(response.OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+			Expect(headers(response)).To(HaveKeyWithValue("Retry-After", "60"))
+		})
+
+		It("should reject uids without config whatever the policy", func() {
+			// Unknown to the policy service
+			Expect(shouldRateLimitDomain("open", descriptor("uid", unknownUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+			Expect(shouldRateLimitDomain("degraded", descriptor("uid", unknownUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+
+			// Without policy service
+			os.Setenv("POLICY_SERVICE_ENDPOINT", "")
+			Expect(shouldRateLimitDomain("open", descriptor("uid", outageUID)).OverallCode).To(Equal(ratelimit.RateLimitResponse_OVER_LIMIT))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+// Copyright 2026 Cisco and its affiliates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+ * File Name : benchmark_test.go
+ * Creation Date : 18-10-2026
+ */
+
+package tests
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/bit-broker/rate-service/internal/helper"
+	"github.com/bit-broker/rate-service/internal/models"
+	"github.com/bit-broker/rate-service/internal/services"
+)
+
+// BenchmarkCheck : Latency of a check, with the config read from Redis
+// every time or from the in-process cache. The disabled config isn't
+// charged, so that only the reading of the config is measured.
+func BenchmarkCheck(b *testing.B) {
+	// Load env
+	helper.LoadEnv(helper.TestEnv)
+	defer os.Unsetenv("CONFIG_CACHE_TTL")
+
+	if err := services.CreateOrUpdatePlan("benchmark", models.Config{Algorithm: models.FixedWindowType}); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		uid := fmt.Sprintf("benchmark-%t", enabled)
+		config := models.Config{
+			Enabled: enabled,
+			Rate:    1 << 30,
+			Quota:   models.Quota{Number: 1 << 30, Interval: models.MonthType},
+			Plan:    "benchmark",
+		}
+		if err := services.CreateOrUpdateConfig(uid, config); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, ttl := range []struct {
+			name  string
+			value string
+		}{{"redis", "0"}, {"cache", "30"}} {
+			b.Run(fmt.Sprintf("enabled=%t/%s", enabled, ttl.name), func(b *testing.B) {
+				os.Setenv("CONFIG_CACHE_TTL", ttl.value)
+
+				b.ResetTimer()
+				for index := 0; index < b.N; index++ {
+					if _, err := services.Check(uid); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
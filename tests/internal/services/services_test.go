@@ -24,15 +24,26 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bit-broker/rate-service/internal/helper"
 	"github.com/bit-broker/rate-service/internal/models"
 	"github.com/bit-broker/rate-service/internal/services"
+	"github.com/bit-broker/rate-service/pkg/redis"
+
+	"github.com/prometheus/client_golang/prometheus"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -45,9 +56,31 @@ var mockupFirstConfigRaw = `{"enabled":true,"quota":{"max_number":10,"interval_t
 var mockupFirstConfig = &models.Config{Enabled: true, Quota: models.Quota{Number: 10, Interval: models.MonthType}, Rate: 5}
 var mockupSecondConfig = &models.Config{Enabled: true, Quota: models.Quota{Number: 5, Interval: models.DayType}, Rate: 20}
 var mockupPolicyServerAddr = ":5001"
+var concurrentUID = strconv.Itoa(100 + rand.Intn(100))
+var concurrentConfig = &models.Config{Enabled: true, Quota: models.Quota{Number: 100, Interval: models.MonthType}, Rate: 1000}
+var concurrentChecks = 500
+var legacyUID = strconv.Itoa(200 + rand.Intn(100))
+var legacyConfigRaw = `{"enabled":true,"quota":{"max_number":10,"interval_type":"month"},"rate":100,"log":{"1600000000":3,"2020-01":7,"%s":8}}`
+var legacyConfig = &models.Config{Enabled: true, Quota: models.Quota{Number: 10, Interval: models.MonthType}, Rate: 100}
+var migrationUID = 900 + 10*rand.Intn(10)
+var algorithmUID = 300 + 10*rand.Intn(10)
+var algorithmQuota = models.Quota{Number: 1000, Interval: models.MonthType}
+var algorithmStart = time.Date(2030, time.January, 10, 12, 0, 0, 0, time.UTC)
 
 // ------------------------ GLOBAL -------------------- //
 
+// counterValue : Current value of a counter metric
+func counterValue(name string) float64 {
+	families, _ := prometheus.DefaultGatherer.Gather()
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
+
 // TestServices : Services Test cases
 func TestControllers(t *testing.T) {
 	// Load env
@@ -58,6 +91,19 @@ func TestControllers(t *testing.T) {
 }
 
 var _ = Describe("Services", func() {
+	// expectChecks : Expect a number of admitted checks followed by a rejected one
+	expectChecks := func(uid string, admitted int) {
+		for index := 0; index < admitted; index++ {
+			status, err := services.Check(uid)
+			Expect(err).To(BeNil())
+			Expect(status).To(BeTrue())
+		}
+
+		status, err := services.Check(uid)
+		Expect(err).To(BeNil())
+		Expect(status).To(BeFalse())
+	}
+
 	Context("Get", func() {
 		It("shouldn't find the config when not created", func() {
 			// Get config
@@ -101,7 +147,6 @@ var _ = Describe("Services", func() {
 			// Check current config
 			config, err := services.GetConfig(uid)
 			Expect(err).To(BeNil())
-			config.Log = nil // Clear the log as check was called
 			Expect(config).To(Equal(*mockupFirstConfig))
 
 			// Close server
@@ -183,4 +228,1800 @@ var _ = Describe("Services", func() {
 			Expect(status).To(BeFalse())
 		})
 	})
+
+	Context("Concurrency", func() {
+		It("should admit exactly the quota under concurrent checks", func() {
+			// Create config
+			services.CreateOrUpdateConfig(concurrentUID, *concurrentConfig)
+
+			// Fire all checks at once, as many in flight as Redis connections
+			var admitted int32
+			var wg sync.WaitGroup
+			inFlight := make(chan struct{}, redis.Client().Options().PoolSize)
+			for index := 0; index < concurrentChecks; index++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					inFlight <- struct{}{}
+					defer func() { <-inFlight }()
+					status, err := services.Check(concurrentUID)
+					Expect(err).To(BeNil())
+					if status {
+						atomic.AddInt32(&admitted, 1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Exactly the quota went through
+			Expect(int(admitted)).To(Equal(concurrentConfig.Quota.Number))
+		})
+	})
+	Context("Migration", func() {
+		BeforeEach(func() {
+			// Migrate again
+			_, err := redis.Client().Del(context.TODO(), "migration:config-keys", "migration:legacy-logs").Result()
+			Expect(err).To(BeNil())
+		})
+
+		It("should carry over the current quota and drop the legacy log", func() {
+			// Store a legacy config
+			currentTime := time.Now()
+			month := fmt.Sprintf("%d-%02d", currentTime.Year(), currentTime.Month())
+			_, err := redis.Client().Set(context.TODO(), legacyUID, fmt.Sprintf(legacyConfigRaw, month), 0).Result()
+			Expect(err).To(BeNil())
+
+			// Migrate
+			err = services.MigrateConfigKeys()
+			Expect(err).To(BeNil())
+			err = services.MigrateLegacyLogs()
+			Expect(err).To(BeNil())
+
+			// Config is moved to its prefix
+			_, err = redis.Client().Get(context.TODO(), legacyUID).Result()
+			Expect(redis.IsNil(err)).To(BeTrue())
+
+			// Log is gone
+			raw, err := redis.Client().Get(context.TODO(), "config:"+legacyUID).Result()
+			Expect(err).To(BeNil())
+			Expect(raw).NotTo(ContainSubstring("log"))
+
+			config, err := services.GetConfig(legacyUID)
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(*legacyConfig))
+
+			// Only what is left of the quota goes through
+			for index := 0; index < 2; index++ {
+				status, err := services.Check(legacyUID)
+				Expect(err).To(BeNil())
+				Expect(status).To(BeTrue())
+			}
+
+			status, err := services.Check(legacyUID)
+			Expect(err).To(BeNil())
+			Expect(status).To(BeFalse())
+		})
+
+		It("should leave the keys of the service untouched", func() {
+			Expect(services.SetCosts(models.Costs{"export": 5})).To(BeNil())
+
+			err := services.MigrateConfigKeys()
+			Expect(err).To(BeNil())
+
+			cost, err := services.GetRouteCost("export")
+			Expect(err).To(BeNil())
+			Expect(cost).To(Equal(5))
+		})
+
+		It("should leave the keys of other applications untouched", func() {
+			others := map[string]string{
+				"session:abc":                  `{"user":"bob"}`,
+				"cache-item":                   `{"enabled":false}`,
+				"user-1":                       mockupFirstConfigRaw,
+				"domain:legacy:cache-item":     `{"enabled":false}`,
+				strconv.Itoa(migrationUID):     `{"user":"bob"}`,
+				strconv.Itoa(migrationUID + 1): `{"rate":5}`,
+				strconv.Itoa(migrationUID + 2): `[5]`,
+			}
+			for key, value := range others {
+				_, err := redis.Client().Set(context.TODO(), key, value, 0).Result()
+				Expect(err).To(BeNil())
+			}
+
+			Expect(services.MigrateConfigKeys()).To(BeNil())
+
+			for key, value := range others {
+				raw, err := redis.Client().Get(context.TODO(), key).Result()
+				Expect(err).To(BeNil())
+				Expect(raw).To(Equal(value))
+			}
+		})
+
+		It("should scan the keyspace once", func() {
+			Expect(services.MigrateConfigKeys()).To(BeNil())
+
+			uid := strconv.Itoa(migrationUID + 3)
+			_, err := redis.Client().Set(context.TODO(), uid, mockupFirstConfigRaw, 0).Result()
+			Expect(err).To(BeNil())
+
+			Expect(services.MigrateConfigKeys()).To(BeNil())
+
+			_, err = redis.Client().Get(context.TODO(), uid).Result()
+			Expect(err).To(BeNil())
+		})
+
+		It("should scan the configs for legacy logs once", func() {
+			Expect(services.MigrateLegacyLogs()).To(BeNil())
+
+			uid := strconv.Itoa(migrationUID + 4)
+			_, err := redis.Client().Set(context.TODO(), "config:"+uid, fmt.Sprintf(legacyConfigRaw, "2020-02"), 0).Result()
+			Expect(err).To(BeNil())
+
+			Expect(services.MigrateLegacyLogs()).To(BeNil())
+
+			raw, err := redis.Client().Get(context.TODO(), "config:"+uid).Result()
+			Expect(err).To(BeNil())
+			Expect(raw).To(ContainSubstring("log"))
+		})
+	})
+	Context("Algorithms", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should reset a fixed window on the second boundary", func() {
+			uid := strconv.Itoa(algorithmUID)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 2, Algorithm: models.FixedWindowType})
+
+			now = algorithmStart.Add(900 * time.Millisecond)
+			expectChecks(uid, 2)
+
+			now = algorithmStart.Add(1000 * time.Millisecond)
+			expectChecks(uid, 2)
+		})
+
+		It("should slide a window log over the last second", func() {
+			uid := strconv.Itoa(algorithmUID + 1)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 2, Algorithm: models.SlidingWindowLogType})
+
+			now = algorithmStart.Add(500 * time.Millisecond)
+			expectChecks(uid, 2)
+
+			now = algorithmStart.Add(1000 * time.Millisecond)
+			expectChecks(uid, 0)
+
+			now = algorithmStart.Add(1500 * time.Millisecond)
+			expectChecks(uid, 2)
+		})
+
+		It("should weight the previous window of a sliding window counter", func() {
+			uid := strconv.Itoa(algorithmUID + 2)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 10, Algorithm: models.SlidingWindowCounterType})
+
+			now = algorithmStart.Add(900 * time.Millisecond)
+			expectChecks(uid, 10)
+
+			now = algorithmStart.Add(1500 * time.Millisecond)
+			expectChecks(uid, 5)
+
+			now = algorithmStart.Add(2000 * time.Millisecond)
+			expectChecks(uid, 5)
+		})
+
+		It("should refill a token bucket up to its burst", func() {
+			uid := strconv.Itoa(algorithmUID + 3)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 2, Burst: 4, Algorithm: models.TokenBucketType})
+
+			expectChecks(uid, 4)
+
+			now = algorithmStart.Add(500 * time.Millisecond)
+			expectChecks(uid, 1)
+
+			now = algorithmStart.Add(10 * time.Second)
+			expectChecks(uid, 4)
+		})
+
+		It("should count the rate per unit", func() {
+			uid := strconv.Itoa(algorithmUID + 4)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 3, RateUnit: models.MinuteUnit})
+
+			now = algorithmStart.Add(10 * time.Second)
+			expectChecks(uid, 3)
+
+			now = algorithmStart.Add(59 * time.Second)
+			expectChecks(uid, 0)
+
+			now = algorithmStart.Add(60 * time.Second)
+			expectChecks(uid, 3)
+		})
+
+		It("should count the rate over an arbitrary window", func() {
+			uid := strconv.Itoa(algorithmUID + 5)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Quota: algorithmQuota, Rate: 2, RateWindow: models.Duration(90 * time.Second), Algorithm: models.SlidingWindowLogType})
+
+			expectChecks(uid, 2)
+
+			now = algorithmStart.Add(89 * time.Second)
+			expectChecks(uid, 0)
+
+			now = algorithmStart.Add(90 * time.Second)
+			expectChecks(uid, 2)
+		})
+	})
+	Context("Limits", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should admit only within every stacked limit", func() {
+			uid := strconv.Itoa(algorithmUID + 6)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 10, Limits: []models.Limit{
+				{Name: "hourly", Number: 15, Unit: models.HourUnit},
+				{Number: 100, Interval: models.MonthType},
+			}})
+
+			// Within the rate
+			for index := 0; index < 10; index++ {
+				result, err := services.Evaluate(uid, 1)
+				Expect(err).To(BeNil())
+				Expect(result.OK).To(BeTrue())
+				Expect(result.Limits).To(HaveLen(3))
+			}
+
+			// Rate exhausted
+			result, err := services.Evaluate(uid, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Limits[result.Exhausted].Name).To(Equal("rate"))
+
+			// Hourly limit exhausted on the next second
+			now = algorithmStart.Add(time.Second)
+			for index := 0; index < 5; index++ {
+				status, err := services.Check(uid)
+				Expect(err).To(BeNil())
+				Expect(status).To(BeTrue())
+			}
+
+			result, err = services.Evaluate(uid, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Limits[result.Exhausted].Name).To(Equal("hourly"))
+		})
+
+		It("should reject the names of limits sharing counters", func() {
+			err := services.ValidateConfig(models.Config{Enabled: true, Rate: 10, Limits: []models.Limit{
+				{Name: "rate", Number: 5},
+				{Name: "quota", Number: 5},
+				{Name: "credit", Number: 5},
+				{Name: "daily:eu", Number: 5},
+				{Name: "hourly", Number: 5},
+				{Name: "hourly", Number: 5},
+				{Name: "limit-7", Number: 5},
+				{Number: 5},
+			}})
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "limits[0].name", Message: `"rate" is reserved`},
+				{Field: "limits[1].name", Message: `"quota" is reserved`},
+				{Field: "limits[2].name", Message: `"credit" is reserved`},
+				{Field: "limits[3].name", Message: `must not contain ":"`},
+				{Field: "limits[5].name", Message: `duplicate name "hourly"`},
+				{Field: "limits[7].name", Message: `duplicate name "limit-7"`},
+			}}))
+
+			// Nodes of a descriptor tree too
+			err = services.ValidateDescriptors([]models.Descriptor{{Key: "path", Limits: []models.Limit{{Name: "rate", Number: 1}}}})
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "descriptors[0].limits[0].name", Message: `"rate" is reserved`},
+			}}))
+		})
+
+		It("should reset weekly limits on ISO week boundaries", func() {
+			uid := strconv.Itoa(algorithmUID + 7)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{
+				{Number: 2, Interval: models.WeekType},
+				{Number: 3, Interval: models.YearType},
+			}})
+
+			// Thursday
+			expectChecks(uid, 2)
+
+			// Sunday
+			now = time.Date(2030, time.January, 13, 23, 59, 59, 0, time.UTC)
+			expectChecks(uid, 0)
+
+			// Monday, only the yearly limit is left
+			now = time.Date(2030, time.January, 14, 0, 0, 0, 0, time.UTC)
+			expectChecks(uid, 1)
+		})
+
+		It("should reset hourly limits on the hour", func() {
+			uid := strconv.Itoa(algorithmUID + 8)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{
+				{Number: 2, Interval: models.HourType},
+			}})
+
+			now = algorithmStart.Add(59 * time.Minute)
+			expectChecks(uid, 2)
+
+			now = algorithmStart.Add(60 * time.Minute)
+			expectChecks(uid, 2)
+		})
+
+		It("should roll a quota over a duration", func() {
+			uid := strconv.Itoa(algorithmUID + 9)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: models.Quota{
+				Number: 2, Interval: models.DurationType, Duration: models.Duration(30 * 24 * time.Hour),
+			}})
+
+			expectChecks(uid, 2)
+
+			// Calendar month changed, but not 30 days
+			now = algorithmStart.AddDate(0, 0, 29)
+			expectChecks(uid, 0)
+
+			now = algorithmStart.AddDate(0, 0, 30)
+			expectChecks(uid, 2)
+		})
+
+		It("should reset days at midnight in their time zone across DST", func() {
+			uid := strconv.Itoa(algorithmUID + 10)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{
+				{Number: 2, Interval: models.DayType, Timezone: "America/New_York"},
+			}})
+
+			// Clocks go forward on the 10th of march, a 23 hours day
+			now = time.Date(2030, time.March, 10, 5, 30, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			now = time.Date(2030, time.March, 11, 3, 59, 59, 0, time.UTC)
+			expectChecks(uid, 0)
+
+			now = time.Date(2030, time.March, 11, 4, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			// Clocks go back on the 3rd of november, a 25 hours day
+			now = time.Date(2030, time.November, 3, 4, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			now = time.Date(2030, time.November, 4, 4, 59, 59, 0, time.UTC)
+			expectChecks(uid, 0)
+
+			now = time.Date(2030, time.November, 4, 5, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+		})
+
+		It("should reset anchored months on the anchor day in their time zone", func() {
+			uid := strconv.Itoa(algorithmUID + 11)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{
+				{Number: 2, Interval: models.MonthType, Timezone: "Europe/Paris", Anchor: "2029-11-17"},
+			}})
+
+			// Still the 16th in Paris
+			now = time.Date(2030, time.January, 16, 22, 30, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			// Midnight of the 17th in Paris
+			now = time.Date(2030, time.January, 16, 23, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			now = time.Date(2030, time.February, 16, 22, 59, 59, 0, time.UTC)
+			expectChecks(uid, 0)
+		})
+
+		It("should reset anchored months on the last day of short months", func() {
+			uid := strconv.Itoa(algorithmUID + 12)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{
+				{Number: 2, Interval: models.MonthType, Anchor: "2029-12-31"},
+			}})
+
+			now = time.Date(2030, time.February, 27, 12, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			// February has 28 days
+			now = time.Date(2030, time.February, 28, 0, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+
+			// Back to the 31st in March
+			now = time.Date(2030, time.March, 30, 23, 59, 59, 0, time.UTC)
+			expectChecks(uid, 0)
+
+			now = time.Date(2030, time.March, 31, 0, 0, 0, 0, time.UTC)
+			expectChecks(uid, 2)
+		})
+	})
+	Context("Charge", func() {
+		var now time.Time
+
+		// chargeLimits : A per minute limit wider than a per second one
+		chargeLimits := []models.Limit{
+			{Name: "minute", Number: 3, Unit: models.MinuteUnit},
+			{Name: "second", Number: 2},
+		}
+
+		// expectExhausted : Expect a rejected check because of the given limit
+		expectExhausted := func(uid string, name string) {
+			result, err := services.Evaluate(uid, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Limits[result.Exhausted].Name).To(Equal(name))
+		}
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should charge nothing on rejection when charging on admit", func() {
+			uid := strconv.Itoa(algorithmUID + 13)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: chargeLimits, Charge: models.OnAdmitCharge})
+
+			// Rejected by the second limit, the minute one isn't charged
+			expectChecks(uid, 2)
+			expectExhausted(uid, "second")
+
+			// Rejected by the minute limit, the second one isn't charged
+			now = algorithmStart.Add(time.Second)
+			expectChecks(uid, 1)
+			expectExhausted(uid, "minute")
+
+			now = algorithmStart.Add(time.Minute)
+			expectChecks(uid, 2)
+		})
+
+		It("should charge nothing on rejection by default", func() {
+			uid := strconv.Itoa(algorithmUID + 14)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: chargeLimits})
+
+			expectChecks(uid, 2)
+
+			now = algorithmStart.Add(time.Second)
+			expectChecks(uid, 1)
+		})
+
+		It("should charge every limit on every attempt", func() {
+			uid := strconv.Itoa(algorithmUID + 15)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: chargeLimits, Charge: models.AllAttemptsCharge})
+
+			// Rejected by the second limit, the minute one is charged anyway
+			expectChecks(uid, 2)
+
+			// Minute limit is exhausted by the rejected attempt
+			now = algorithmStart.Add(time.Second)
+			expectExhausted(uid, "minute")
+
+			now = algorithmStart.Add(time.Minute)
+			expectChecks(uid, 2)
+		})
+	})
+	Context("Hits", func() {
+		var now time.Time
+
+		// expectHits : Expect a check of the given hits
+		expectHits := func(uid string, hits int, ok bool) {
+			result, err := services.Evaluate(uid, hits)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(Equal(ok))
+		}
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should charge the hits against every limit", func() {
+			uid := strconv.Itoa(algorithmUID + 16)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 10, Quota: models.Quota{Number: 12, Interval: models.MonthType}})
+
+			// Over the rate, nothing is charged
+			expectHits(uid, 8, true)
+			expectHits(uid, 3, false)
+			expectHits(uid, 2, true)
+
+			// Over the quota, nothing is charged
+			now = algorithmStart.Add(time.Second)
+			expectHits(uid, 3, false)
+			expectHits(uid, 2, true)
+			expectHits(uid, 1, false)
+		})
+
+		It("should take the hits from a token bucket and a window log", func() {
+			uid := strconv.Itoa(algorithmUID + 17)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, Burst: 4, Algorithm: models.TokenBucketType, Limits: []models.Limit{
+				{Number: 6, Unit: models.MinuteUnit, Algorithm: models.SlidingWindowLogType},
+			}})
+
+			expectHits(uid, 3, true)
+			expectHits(uid, 2, false)
+			expectHits(uid, 1, true)
+
+			// Bucket is full again, the log isn't
+			now = algorithmStart.Add(10 * time.Second)
+			expectHits(uid, 3, false)
+			expectHits(uid, 2, true)
+		})
+
+		It("should log a cost at once and never charge a cost over the limit", func() {
+			uid := strconv.Itoa(algorithmUID + 40)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Charge: models.AllAttemptsCharge, Limits: []models.Limit{
+				{Name: "minute", Number: 10, Unit: models.MinuteUnit, Algorithm: models.SlidingWindowLogType},
+			}})
+
+			result, err := services.Evaluate(uid, 3)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Usages[0].Remaining).To(Equal(7))
+
+			// The largest hits addend, even though every attempt is charged
+			result, err = services.Evaluate(uid, int(math.MaxUint32))
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+			Expect(result.Usages[0].Remaining).To(Equal(7))
+
+			// A single member per charge
+			members, err := redis.Client().ZCard(context.TODO(), "counter:"+uid+":minute:log").Result()
+			Expect(err).To(BeNil())
+			Expect(members).To(Equal(int64(1)))
+
+			expectHits(uid, 7, true)
+			expectHits(uid, 1, false)
+		})
+	})
+
+	Context("Costs", func() {
+		It("should set the route costs", func() {
+			err := services.SetCosts(models.Costs{"export": 5, "lookup": 1})
+			Expect(err).To(BeNil())
+
+			costs, err := services.GetCosts()
+			Expect(err).To(BeNil())
+			Expect(costs).To(Equal(models.Costs{"export": 5, "lookup": 1}))
+		})
+
+		It("should find the cost of a route", func() {
+			cost, err := services.GetRouteCost("export")
+			Expect(err).To(BeNil())
+			Expect(cost).To(Equal(5))
+		})
+
+		It("should default to a single hit", func() {
+			cost, err := services.GetRouteCost("unknown")
+			Expect(err).To(BeNil())
+			Expect(cost).To(Equal(1))
+		})
+
+		It("should replace the route costs", func() {
+			err := services.SetCosts(models.Costs{})
+			Expect(err).To(BeNil())
+
+			cost, err := services.GetRouteCost("export")
+			Expect(err).To(BeNil())
+			Expect(cost).To(Equal(1))
+		})
+	})
+	Context("Descriptors", func() {
+		uid := strconv.Itoa(algorithmUID + 18)
+
+		// expectDescriptor : Expect a number of admitted descriptors followed by a rejected one
+		expectDescriptor := func(entries []models.Entry, admitted int) {
+			for index := 0; index < admitted; index++ {
+				result, err := services.EvaluateDescriptor(services.DefaultDomain, entries, 1)
+				Expect(err).To(BeNil())
+				Expect(result.OK).To(BeTrue())
+			}
+
+			result, err := services.EvaluateDescriptor(services.DefaultDomain, entries, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+		}
+
+		BeforeEach(func() {
+			services.SetClock(func() time.Time { return algorithmStart })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should limit the descriptors of a uid", func() {
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: algorithmQuota, Descriptors: []models.Descriptor{
+				{Key: "path", Value: "/export", Limits: []models.Limit{{Name: "export", Number: 2}}},
+				{Key: "method", Limits: []models.Limit{{Number: 1}}},
+			}})
+
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}, {Key: "path", Value: "/export"}}, 2)
+
+			// Any value, counted per value
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}, {Key: "method", Value: "GET"}}, 1)
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}, {Key: "method", Value: "POST"}}, 1)
+		})
+
+		It("should fall back to the limits of the uid", func() {
+			result, err := services.EvaluateDescriptor(services.DefaultDomain, []models.Entry{{Key: "uid", Value: uid}, {Key: "path", Value: "/other"}}, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Limits[0].Name).To(Equal("rate"))
+
+			status, err := services.Check(uid)
+			Expect(err).To(BeNil())
+			Expect(status).To(BeTrue())
+		})
+
+		It("should match the deepest descriptor with limits", func() {
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: algorithmQuota, Descriptors: []models.Descriptor{
+				{Key: "path", Value: "/import", Limits: []models.Limit{{Number: 3}}, Descriptors: []models.Descriptor{
+					{Key: "method", Value: "POST", Limits: []models.Limit{{Number: 1}}},
+					{Key: "method", Value: "GET"},
+				}},
+			}})
+
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}, {Key: "path", Value: "/import"}, {Key: "method", Value: "POST"}}, 1)
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}, {Key: "path", Value: "/import"}, {Key: "method", Value: "GET"}}, 3)
+		})
+
+		It("should limit the global descriptors", func() {
+			err := services.SetDescriptors(services.DefaultDomain, []models.Descriptor{
+				{Key: "remote_address", Limits: []models.Limit{{Number: 2}}},
+			})
+			Expect(err).To(BeNil())
+
+			expectDescriptor([]models.Entry{{Key: "remote_address", Value: "10.0.0.1"}}, 2)
+			expectDescriptor([]models.Entry{{Key: "remote_address", Value: "10.0.0.2"}}, 2)
+		})
+
+		It("shouldn't share the counters of values holding separators", func() {
+			err := services.SetDescriptors(services.DefaultDomain, []models.Descriptor{
+				{Key: "client", Limits: []models.Limit{{Number: 1}}, Descriptors: []models.Descriptor{
+					{Key: "path", Limits: []models.Limit{{Number: 1}}},
+				}},
+			})
+			Expect(err).To(BeNil())
+
+			expectDescriptor([]models.Entry{{Key: "client", Value: "fe80::1:path=/x"}}, 1)
+			expectDescriptor([]models.Entry{{Key: "client", Value: "fe80::1"}, {Key: "path", Value: "/x"}}, 1)
+		})
+
+		It("shouldn't limit unknown descriptors", func() {
+			result, err := services.EvaluateDescriptor(services.DefaultDomain, []models.Entry{{Key: "user_agent", Value: "curl"}}, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeTrue())
+			Expect(result.Limits).To(BeEmpty())
+		})
+
+		It("should charge the limits of a uid once per request", func() {
+			uid := strconv.Itoa(algorithmUID + 35)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{{Name: "burst", Number: 2}}})
+
+			descriptors := [][]models.Entry{
+				{{Key: "uid", Value: uid}},
+				{{Key: "uid", Value: uid}, {Key: "path", Value: "/x"}},
+			}
+			for index := 0; index < 2; index++ {
+				results, errs := services.EvaluateDescriptors(services.DefaultDomain, descriptors, 1)
+				Expect(errs).To(Equal([]error{nil, nil}))
+				Expect(results[0].OK).To(BeTrue())
+				Expect(results[1].OK).To(BeTrue())
+			}
+
+			results, errs := services.EvaluateDescriptors(services.DefaultDomain, descriptors, 1)
+			Expect(errs).To(Equal([]error{nil, nil}))
+			Expect(results[0].OK).To(BeFalse())
+			Expect(results[1].OK).To(BeFalse())
+		})
+
+		It("shouldn't charge any descriptor of a rejected request", func() {
+			uid := strconv.Itoa(algorithmUID + 36)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{{Name: "burst", Number: 3}}, Descriptors: []models.Descriptor{
+				{Key: "path", Value: "/export", Limits: []models.Limit{{Name: "export", Number: 1}}},
+			}})
+
+			descriptors := [][]models.Entry{
+				{{Key: "uid", Value: uid}},
+				{{Key: "uid", Value: uid}, {Key: "path", Value: "/export"}},
+			}
+			results, errs := services.EvaluateDescriptors(services.DefaultDomain, descriptors, 1)
+			Expect(errs).To(Equal([]error{nil, nil}))
+			Expect(results[0].OK).To(BeTrue())
+			Expect(results[1].OK).To(BeTrue())
+
+			// The export is exhausted, the uid limits are left untouched
+			for index := 0; index < 3; index++ {
+				results, errs = services.EvaluateDescriptors(services.DefaultDomain, descriptors, 1)
+				Expect(errs).To(Equal([]error{nil, nil}))
+				Expect(results[0].OK).To(BeTrue())
+				Expect(results[0].Usages[0].Remaining).To(Equal(2))
+				Expect(results[1].OK).To(BeFalse())
+			}
+
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}}, 2)
+		})
+
+		It("shouldn't charge any descriptor along with a disabled or unknown uid", func() {
+			// Without policy service to fetch the unknown uid from
+			endpoint := os.Getenv("POLICY_SERVICE_ENDPOINT")
+			os.Setenv("POLICY_SERVICE_ENDPOINT", "")
+			defer os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+
+			uid := strconv.Itoa(algorithmUID + 37)
+			disabledUID := strconv.Itoa(algorithmUID + 38)
+			unknownUID := strconv.Itoa(algorithmUID + 39)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Limits: []models.Limit{{Name: "burst", Number: 3}}})
+			services.CreateOrUpdateConfig(disabledUID, models.Config{Enabled: false, Limits: []models.Limit{{Name: "burst", Number: 3}}})
+
+			for _, other := range []string{disabledUID, unknownUID} {
+				descriptors := [][]models.Entry{
+					{{Key: "uid", Value: uid}},
+					{{Key: "uid", Value: other}},
+				}
+				for index := 0; index < 5; index++ {
+					results, _ := services.EvaluateDescriptors(services.DefaultDomain, descriptors, 1)
+					Expect(results[0].Usages[0].Remaining).To(Equal(3))
+					Expect(results[1].OK).To(BeFalse())
+				}
+			}
+
+			// The counters are left untouched
+			expectDescriptor([]models.Entry{{Key: "uid", Value: uid}}, 3)
+		})
+	})
+	Context("Usage", func() {
+		var now time.Time
+
+		// expectUsage : Expect the usage of the reported limit after a check
+		expectUsage := func(uid string, name string, remaining int, reset time.Duration) {
+			result, err := services.Evaluate(uid, 1)
+			Expect(err).To(BeNil())
+
+			limit, _, ok := result.Current()
+			Expect(ok).To(BeTrue())
+			Expect(limit.Name).To(Equal(name))
+
+			usage, ok := result.CurrentUsage()
+			Expect(ok).To(BeTrue())
+			Expect(usage).To(Equal(services.Usage{Remaining: remaining, Reset: reset}))
+		}
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should report the fixed window with the fewest hits left", func() {
+			uid := strconv.Itoa(algorithmUID + 19)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 3, RateUnit: models.MinuteUnit, Quota: algorithmQuota})
+
+			expectUsage(uid, "rate", 2, time.Minute)
+
+			now = algorithmStart.Add(15 * time.Second)
+			expectUsage(uid, "rate", 1, 45*time.Second)
+			expectUsage(uid, "rate", 0, 45*time.Second)
+		})
+
+		It("should report the exhausted quota until the next month", func() {
+			uid := strconv.Itoa(algorithmUID + 20)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: models.Quota{Number: 1, Interval: models.MonthType}})
+
+			expectUsage(uid, "quota", 0, 22*24*time.Hour-12*time.Hour)
+			expectUsage(uid, "quota", 0, 22*24*time.Hour-12*time.Hour)
+		})
+
+		It("should report the sliding window log until its oldest hit expires", func() {
+			uid := strconv.Itoa(algorithmUID + 21)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, RateUnit: models.MinuteUnit, Algorithm: models.SlidingWindowLogType, Quota: algorithmQuota})
+
+			expectUsage(uid, "rate", 1, time.Minute)
+
+			now = algorithmStart.Add(20 * time.Second)
+			expectUsage(uid, "rate", 0, 40*time.Second)
+		})
+
+		It("should report the token bucket until its next token", func() {
+			uid := strconv.Itoa(algorithmUID + 22)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, Burst: 2, Algorithm: models.TokenBucketType, Quota: algorithmQuota})
+
+			expectUsage(uid, "rate", 1, 500*time.Millisecond)
+			expectUsage(uid, "rate", 0, 500*time.Millisecond)
+
+			now = algorithmStart.Add(250 * time.Millisecond)
+			expectUsage(uid, "rate", 0, 250*time.Millisecond)
+		})
+	})
+	Context("Domains", func() {
+		uid := strconv.Itoa(algorithmUID + 23)
+
+		// expectDomainChecks : Expect a number of admitted checks followed by a rejected one in a domain
+		expectDomainChecks := func(domain string, admitted int) {
+			entries := []models.Entry{{Key: "uid", Value: uid}}
+			for index := 0; index < admitted; index++ {
+				result, err := services.EvaluateDescriptor(domain, entries, 1)
+				Expect(err).To(BeNil())
+				Expect(result.OK).To(BeTrue())
+			}
+
+			result, err := services.EvaluateDescriptor(domain, entries, 1)
+			Expect(err).To(BeNil())
+			Expect(result.OK).To(BeFalse())
+		}
+
+		BeforeEach(func() {
+			services.SetClock(func() time.Time { return algorithmStart })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should store the configs per domain", func() {
+			err := services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 3, Quota: algorithmQuota})
+			Expect(err).To(BeNil())
+
+			err = services.CreateOrUpdateDomainConfig("staging", uid, models.Config{Enabled: true, Rate: 1, Quota: algorithmQuota})
+			Expect(err).To(BeNil())
+
+			config, err := services.GetDomainConfig("staging", uid)
+			Expect(err).To(BeNil())
+			Expect(config.Rate).To(Equal(1))
+
+			config, err = services.GetDomainConfig(services.DefaultDomain, uid)
+			Expect(err).To(BeNil())
+			Expect(config.Rate).To(Equal(3))
+		})
+
+		It("should count the requests per domain", func() {
+			expectDomainChecks("staging", 1)
+			expectDomainChecks(services.DefaultDomain, 3)
+		})
+
+		It("should fall back to the config of the default domain", func() {
+			// Counts its own requests against the config of the default domain
+			expectDomainChecks("production", 3)
+		})
+
+		It("should delete the config of a domain only", func() {
+			err := services.DeleteDomainConfig("staging", uid)
+			Expect(err).To(BeNil())
+
+			_, err = services.GetDomainConfig("staging", uid)
+			Expect(err).NotTo(BeNil())
+
+			_, err = services.GetConfig(uid)
+			Expect(err).To(BeNil())
+		})
+	})
+	Context("Plans", func() {
+		var now time.Time
+		uid := strconv.Itoa(algorithmUID + 24)
+		overrideUID := strconv.Itoa(algorithmUID + 25)
+		unknownUID := strconv.Itoa(algorithmUID + 26)
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+			os.Unsetenv("DEFAULT_PLAN")
+		})
+
+		It("should validate the plans", func() {
+			Expect(services.ValidatePlan("free", models.Config{Rate: 2})).To(BeNil())
+			Expect(services.ValidatePlan("free:trial", models.Config{Rate: 2})).NotTo(BeNil())
+			Expect(services.ValidatePlan("free", models.Config{Plan: "pro"})).NotTo(BeNil())
+			Expect(services.ValidateConfig(models.Config{Enabled: true, Plan: "unknown"})).NotTo(BeNil())
+		})
+
+		It("should apply the plan of a config", func() {
+			err := services.CreateOrUpdatePlan("free", models.Config{Rate: 2, Quota: algorithmQuota})
+			Expect(err).To(BeNil())
+
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Plan: "free"})
+			expectChecks(uid, 2)
+		})
+
+		It("should follow the changes of the plan", func() {
+			err := services.CreateOrUpdatePlan("free", models.Config{Rate: 3, Quota: algorithmQuota})
+			Expect(err).To(BeNil())
+
+			now = algorithmStart.Add(time.Second)
+			expectChecks(uid, 3)
+		})
+
+		It("should override the plan with the fields of the config", func() {
+			services.CreateOrUpdateConfig(overrideUID, models.Config{Enabled: true, Plan: "free", Rate: 1})
+			expectChecks(overrideUID, 1)
+		})
+
+		It("should apply the default plan to unknown uids", func() {
+			status, err := services.Check(unknownUID)
+			Expect(err).NotTo(BeNil())
+			Expect(status).To(BeFalse())
+
+			os.Setenv("DEFAULT_PLAN", "free")
+			expectChecks(unknownUID, 3)
+
+			// Not stored
+			_, err = services.GetConfig(unknownUID)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should list the plans", func() {
+			err := services.CreateOrUpdatePlan("pro", models.Config{Rate: 100, Quota: algorithmQuota})
+			Expect(err).To(BeNil())
+
+			plans, err := services.GetPlans()
+			Expect(err).To(BeNil())
+			Expect(plans).To(HaveLen(2))
+			Expect(plans["pro"].Rate).To(Equal(100))
+		})
+
+		It("should reject the checks of a deleted plan", func() {
+			err := services.DeletePlan("pro")
+			Expect(err).To(BeNil())
+
+			services.CreateOrUpdateConfig(overrideUID, models.Config{Enabled: true, Plan: "pro"})
+			_, err = services.Check(overrideUID)
+			Expect(err).NotTo(BeNil())
+		})
+	})
+	Context("Fetch", func() {
+		var server *httptest.Server
+		var requests int32
+		var handler func(w http.ResponseWriter, r *http.Request)
+		endpoint := os.Getenv("POLICY_SERVICE_ENDPOINT")
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&requests, 0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				handler(w, r)
+			}))
+			os.Setenv("POLICY_SERVICE_ENDPOINT", server.URL+"/configs/{uid}")
+		})
+
+		AfterEach(func() {
+			server.Close()
+			os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+			os.Unsetenv("POLICY_SERVICE_AUTHORIZATION")
+			os.Unsetenv("POLICY_SERVICE_RETRIES")
+			os.Unsetenv("POLICY_SERVICE_BACKOFF")
+			os.Unsetenv("POLICY_SERVICE_TIMEOUT")
+			services.SetClock(nil)
+		})
+
+		It("should ask for the config of the uid", func() {
+			var path, authorization string
+			os.Setenv("POLICY_SERVICE_AUTHORIZATION", "Bearer token")
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				path, authorization = r.URL.EscapedPath(), r.Header.Get("Authorization")
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+
+			config, err := services.FetchConfig("fetch/path")
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(*mockupFirstConfig))
+			Expect(path).To(Equal("/configs/fetch%2Fpath"))
+			Expect(authorization).To(Equal("Bearer token"))
+		})
+
+		It("should send the uid as a query parameter without placeholder", func() {
+			var query url.Values
+			os.Setenv("POLICY_SERVICE_ENDPOINT", server.URL+"/configs?tenant=1")
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query()
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+
+			_, err := services.FetchConfig("fetch query")
+			Expect(err).To(BeNil())
+			Expect(query.Get("uid")).To(Equal("fetch query"))
+			Expect(query.Get("tenant")).To(Equal("1"))
+		})
+
+		It("should remember the unknown uids", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"not found"}`))
+			}
+
+			_, err := services.FetchConfig("fetch-unknown")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+			_, err = services.FetchConfig("fetch-unknown")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+
+			// Not cached as a config
+			_, err = services.GetConfig("fetch-unknown")
+			Expect(err).NotTo(BeNil())
+
+			// Asked again once its config is deleted
+			services.DeleteConfig("fetch-unknown")
+			_, err = services.FetchConfig("fetch-unknown")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+		})
+
+		It("should ask again for the unknown uids once forgotten", func() {
+			now := time.Now()
+			services.SetClock(func() time.Time { return now })
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+			_, err := services.FetchConfig("fetch-forgotten")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+
+			now = now.Add(59 * time.Second)
+			_, err = services.FetchConfig("fetch-forgotten")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+
+			now = now.Add(2 * time.Second)
+			_, err = services.FetchConfig("fetch-forgotten")
+			Expect(err).To(Equal(services.ErrUnknownUID))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+		})
+
+		It("should retry in the background of the checks", func() {
+			uid := strconv.Itoa(algorithmUID + 43)
+			os.Setenv("POLICY_SERVICE_BACKOFF", "5000")
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadInt32(&requests) < 2 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+			defer services.DeleteConfig(uid)
+
+			// Without waiting for the backoff
+			start := time.Now()
+			_, err := services.Check(uid)
+			Expect(errors.Is(err, services.ErrUnavailable)).To(BeTrue())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			// Fetched in the background
+			Eventually(func() error {
+				_, err := services.GetStoredConfig(services.DefaultDomain, uid)
+				return err
+			}).Should(BeNil())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+
+			_, err = services.Check(uid)
+			Expect(err).To(BeNil())
+		})
+
+		It("should retry the server errors", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadInt32(&requests) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+
+			config, err := services.FetchConfig("fetch-retry")
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(*mockupFirstConfig))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+		})
+
+		It("should give up after the retries", func() {
+			os.Setenv("POLICY_SERVICE_RETRIES", "1")
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+
+			_, err := services.FetchConfig("fetch-failure")
+			Expect(err).NotTo(BeNil())
+			Expect(err).NotTo(Equal(services.ErrUnknownUID))
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+
+			// Not remembered as unknown
+			_, err = services.FetchConfig("fetch-failure")
+			Expect(err).NotTo(BeNil())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(4)))
+		})
+
+		It("shouldn't retry the client errors", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+
+			_, err := services.FetchConfig("fetch-unauthorized")
+			Expect(err).NotTo(BeNil())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("should reject the invalid bodies", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`<html>Error</html>`))
+			}
+
+			_, err := services.FetchConfig("fetch-invalid")
+			Expect(err).NotTo(BeNil())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("should reject the invalid configs", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"enabled":true,"quota":{"max_number":10,"interval_type":"fortnight"}}`))
+			}
+
+			_, err := services.FetchConfig("fetch-invalid-config")
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should give up when the server is too slow", func() {
+			os.Setenv("POLICY_SERVICE_RETRIES", "0")
+			os.Setenv("POLICY_SERVICE_TIMEOUT", "1")
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}
+
+			_, err := services.FetchConfig("fetch-timeout")
+			Expect(err).NotTo(BeNil())
+			Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+		})
+
+		It("should mark the fetched configs", func() {
+			services.SetClock(func() time.Time { return algorithmStart })
+			defer services.SetClock(nil)
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+
+			status, err := services.Check("fetch-source")
+			Expect(err).To(BeNil())
+			Expect(status).To(BeTrue())
+
+			Eventually(func() models.SourceType {
+				stored, _ := services.GetStoredConfig(services.DefaultDomain, "fetch-source")
+				return stored.Source
+			}).Should(Equal(models.PolicyServiceSource))
+
+			stored, err := services.GetStoredConfig(services.DefaultDomain, "fetch-source")
+			Expect(err).To(BeNil())
+			Expect(stored.Config).To(Equal(*mockupFirstConfig))
+			Expect(stored.FetchedAt.Equal(algorithmStart)).To(BeTrue())
+
+			// Set locally
+			services.CreateOrUpdateConfig("fetch-source", *mockupFirstConfig)
+			stored, err = services.GetStoredConfig(services.DefaultDomain, "fetch-source")
+			Expect(err).To(BeNil())
+			Expect(stored.Source).To(Equal(models.LocalSource))
+			Expect(stored.FetchedAt).To(BeNil())
+		})
+
+		It("should refresh the stale configs while still using them", func() {
+			now := algorithmStart
+			services.SetClock(func() time.Time { return now })
+			defer services.SetClock(nil)
+			rate := int32(5)
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprintf(w, `{"enabled":true,"quota":{"max_number":1000,"interval_type":"month"},"rate":%d}`, atomic.LoadInt32(&rate))
+			}
+
+			_, err := services.Check("fetch-refresh")
+			Expect(err).To(BeNil())
+			Eventually(func() error {
+				_, err := services.GetConfig("fetch-refresh")
+				return err
+			}).Should(BeNil())
+
+			// Not refreshed while fresh
+			atomic.StoreInt32(&rate, 2)
+			now = algorithmStart.Add(time.Minute)
+			_, err = services.Check("fetch-refresh")
+			Expect(err).To(BeNil())
+			Consistently(func() int32 { return atomic.LoadInt32(&requests) }, "100ms").Should(Equal(int32(1)))
+
+			// Still used once stale, then refreshed
+			now = algorithmStart.Add(10 * time.Minute)
+			status, err := services.Check("fetch-refresh")
+			Expect(err).To(BeNil())
+			Expect(status).To(BeTrue())
+			Eventually(func() int {
+				config, _ := services.GetConfig("fetch-refresh")
+				return config.Rate
+			}).Should(Equal(2))
+
+			stored, err := services.GetStoredConfig(services.DefaultDomain, "fetch-refresh")
+			Expect(err).To(BeNil())
+			Expect(stored.FetchedAt.Equal(now)).To(BeTrue())
+		})
+
+		It("should delete the refreshed configs of the unknown uids", func() {
+			now := algorithmStart
+			services.SetClock(func() time.Time { return now })
+			defer services.SetClock(nil)
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if atomic.LoadInt32(&requests) > 1 {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(mockupFirstConfigRaw))
+			}
+
+			_, err := services.Check("fetch-removed")
+			Expect(err).To(BeNil())
+			Eventually(func() error {
+				_, err := services.GetConfig("fetch-removed")
+				return err
+			}).Should(BeNil())
+
+			now = algorithmStart.Add(10 * time.Minute)
+			_, err = services.Check("fetch-removed")
+			Expect(err).To(BeNil())
+			Eventually(func() error {
+				_, err := services.GetConfig("fetch-removed")
+				return err
+			}).ShouldNot(BeNil())
+		})
+
+		It("should apply the events of the policy service", func() {
+			rate := int32(5)
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprintf(w, `{"enabled":true,"quota":{"max_number":1000,"interval_type":"month"},"rate":%d}`, atomic.LoadInt32(&rate))
+			}
+
+			for _, eventUID := range []string{"event-changed", "event-revoked"} {
+				_, err := services.Check(eventUID)
+				Expect(err).To(BeNil())
+				Eventually(func() error {
+					_, err := services.GetConfig(eventUID)
+					return err
+				}).Should(BeNil())
+			}
+			services.CreateOrUpdateConfig("event-local", *mockupFirstConfig)
+
+			atomic.StoreInt32(&rate, 2)
+			event := models.PolicyEvent{ID: "event", Changed: []string{"event-changed", "event-unfetched"}, Revoked: []string{"event-revoked", "event-local"}}
+			Expect(services.ValidatePolicyEvent(event)).To(BeNil())
+			Expect(services.ApplyPolicyEvent(event)).To(BeNil())
+
+			// Fetched again
+			config, err := services.GetConfig("event-changed")
+			Expect(err).To(BeNil())
+			Expect(config.Rate).To(Equal(2))
+
+			// Not fetched until requested
+			_, err = services.GetConfig("event-unfetched")
+			Expect(err).NotTo(BeNil())
+
+			// Deleted
+			_, err = services.GetConfig("event-revoked")
+			Expect(err).NotTo(BeNil())
+
+			// Set locally
+			config, err = services.GetConfig("event-local")
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(*mockupFirstConfig))
+		})
+
+		It("should claim the events once", func() {
+			claimed, err := services.ClaimPolicyEvent("event-claim", []byte(`{"id":"event-claim"}`))
+			Expect(err).To(BeNil())
+			Expect(claimed).To(BeTrue())
+
+			claimed, err = services.ClaimPolicyEvent("event-claim", []byte(`{"id":"event-claim"}`))
+			Expect(err).To(BeNil())
+			Expect(claimed).To(BeFalse())
+
+			_, err = services.ClaimPolicyEvent("event-claim", []byte(`{"id":"event-claim","revoked":["1"]}`))
+			Expect(err).To(Equal(services.ErrEventConflict))
+
+			// Claimed again once released
+			Expect(services.ReleasePolicyEvent("event-claim")).To(BeNil())
+			claimed, err = services.ClaimPolicyEvent("event-claim", []byte(`{"id":"event-claim"}`))
+			Expect(err).To(BeNil())
+			Expect(claimed).To(BeTrue())
+		})
+
+		It("should retry when the server is unreachable", func() {
+			server.Close()
+
+			_, err := services.FetchConfig("fetch-unreachable")
+			Expect(err).NotTo(BeNil())
+			Expect(err).NotTo(Equal(services.ErrUnknownUID))
+		})
+	})
+	Context("Cache", func() {
+		uid := strconv.Itoa(algorithmUID + 27)
+
+		BeforeEach(func() {
+			services.SetClock(func() time.Time { return algorithmStart })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+			os.Unsetenv("CONFIG_CACHE_TTL")
+		})
+
+		It("should read the configs from the cache", func() {
+			// Written without invalidation, never read before
+			uid := strconv.Itoa(algorithmUID + 41)
+			_, err := redis.Client().Set(context.Background(), "config:"+uid, `{"enabled":true,"rate":100,"quota":{"max_number":1000,"interval_type":"month"}}`, 0).Result()
+			Expect(err).To(BeNil())
+
+			misses := counterValue("rate_service_config_cache_misses_total")
+			hits := counterValue("rate_service_config_cache_hits_total")
+			for index := 0; index < 10; index++ {
+				_, err := services.Check(uid)
+				Expect(err).To(BeNil())
+			}
+
+			// Read once from Redis
+			Expect(counterValue("rate_service_config_cache_misses_total") - misses).To(Equal(1.0))
+			Expect(counterValue("rate_service_config_cache_hits_total") - hits).To(Equal(9.0))
+		})
+
+		It("should drop the configs changed by another instance", func() {
+			uid := strconv.Itoa(algorithmUID + 42)
+			key := "config:" + uid
+
+			// rate : Rate of the config read by a check
+			rate := func() int {
+				result, err := services.Evaluate(uid, 1)
+				Expect(err).To(BeNil())
+				return result.Limits[0].Number
+			}
+
+			_, err := redis.Client().Set(context.Background(), key, `{"enabled":true,"rate":2,"quota":{"max_number":1000,"interval_type":"month"}}`, 0).Result()
+			Expect(err).To(BeNil())
+			Expect(rate()).To(Equal(2))
+
+			// Written by another instance, the cached config still applies
+			_, err = redis.Client().Set(context.Background(), key, `{"enabled":true,"rate":5,"quota":{"max_number":1000,"interval_type":"month"}}`, 0).Result()
+			Expect(err).To(BeNil())
+			Expect(rate()).To(Equal(2))
+
+			// Until told by the other instance, once subscribed
+			Eventually(func() int {
+				Expect(redis.Client().Publish(context.Background(), "invalidations", key).Err()).To(BeNil())
+				return rate()
+			}).Should(Equal(5))
+		})
+
+		It("should drop the updated configs from the cache", func() {
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, Quota: algorithmQuota})
+
+			services.SetClock(func() time.Time { return algorithmStart.Add(time.Second) })
+			expectChecks(uid, 2)
+		})
+
+		It("should drop the deleted configs from the cache", func() {
+			services.DeleteConfig(uid)
+
+			_, err := services.Check(uid)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("should read the configs from Redis without cache", func() {
+			os.Setenv("CONFIG_CACHE_TTL", "0")
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, Quota: algorithmQuota})
+			_, err := services.Check(uid)
+			Expect(err).To(BeNil())
+
+			// Written behind the back of the service
+			_, err = redis.Client().Set(context.Background(), "config:"+uid, `{"enabled":true,"rate":5,"quota":{"max_number":1000,"interval_type":"month"}}`, 0).Result()
+			Expect(err).To(BeNil())
+
+			services.SetClock(func() time.Time { return algorithmStart.Add(2 * time.Second) })
+			expectChecks(uid, 5)
+		})
+	})
+	Context("Usage report", func() {
+		var now time.Time
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should report the fixed windows without charging them", func() {
+			uid := strconv.Itoa(algorithmUID + 28)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 3, RateUnit: models.MinuteUnit, Quota: models.Quota{Number: 10, Interval: models.MonthType}})
+
+			now = algorithmStart.Add(15 * time.Second)
+			for index := 0; index < 2; index++ {
+				status, err := services.Check(uid)
+				Expect(err).To(BeNil())
+				Expect(status).To(BeTrue())
+			}
+
+			// Reading the usage twice charges nothing
+			for index := 0; index < 2; index++ {
+				usage, err := services.GetUsage(services.DefaultDomain, uid)
+				Expect(err).To(BeNil())
+				Expect(usage.UID).To(Equal(uid))
+				Expect(usage.Enabled).To(BeTrue())
+				Expect(usage.Limits).To(HaveLen(2))
+
+				rate := usage.Limits[0]
+				Expect(rate.Name).To(Equal("rate"))
+				Expect(rate.Algorithm).To(Equal(models.FixedWindowType))
+				Expect(rate.Window).To(Equal(models.Duration(time.Minute)))
+				Expect(rate.WindowStart.Equal(algorithmStart)).To(BeTrue())
+				Expect(rate.WindowEnd.Equal(algorithmStart.Add(time.Minute))).To(BeTrue())
+				Expect(rate.Used).To(Equal(2))
+				Expect(rate.Remaining).To(Equal(1))
+				Expect(rate.ResetAt.Equal(algorithmStart.Add(time.Minute))).To(BeTrue())
+
+				quota := usage.Limits[1]
+				Expect(quota.Name).To(Equal("quota"))
+				Expect(quota.Window).To(BeZero())
+				Expect(quota.WindowStart.Equal(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+				Expect(quota.WindowEnd.Equal(time.Date(2030, time.February, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+				Expect(quota.Used).To(Equal(2))
+				Expect(quota.Remaining).To(Equal(8))
+				Expect(quota.ResetAt.Equal(*quota.WindowEnd)).To(BeTrue())
+			}
+		})
+
+		It("should report the rolling limits against their burst", func() {
+			uid := strconv.Itoa(algorithmUID + 29)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 2, Burst: 4, Algorithm: models.TokenBucketType, Quota: algorithmQuota})
+
+			expectChecks(uid, 4)
+
+			usage, err := services.GetUsage(services.DefaultDomain, uid)
+			Expect(err).To(BeNil())
+
+			rate := usage.Limits[0]
+			Expect(rate.Algorithm).To(Equal(models.TokenBucketType))
+			Expect(rate.WindowStart).To(BeNil())
+			Expect(rate.WindowEnd).To(BeNil())
+			Expect(rate.Used).To(Equal(4))
+			Expect(rate.Remaining).To(Equal(0))
+			Expect(rate.ResetAt.Equal(algorithmStart.Add(500 * time.Millisecond))).To(BeTrue())
+		})
+
+		It("should not report a uid without config", func() {
+			// Without policy service to fetch it from
+			endpoint := os.Getenv("POLICY_SERVICE_ENDPOINT")
+			os.Setenv("POLICY_SERVICE_ENDPOINT", "")
+			defer os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+
+			_, err := services.GetUsage(services.DefaultDomain, strconv.Itoa(algorithmUID+30))
+			Expect(err).To(Equal(services.ErrNoConfig))
+		})
+	})
+	Context("Counter operations", func() {
+		var now time.Time
+		support := models.Audit{Actor: "support", Reason: "goodwill"}
+
+		// usageOf : Usage of the limits of a uid, per name
+		usageOf := func(uid string) (models.Usage, map[string]models.LimitUsage) {
+			usage, err := services.GetUsage(services.DefaultDomain, uid)
+			Expect(err).To(BeNil())
+
+			named := make(map[string]models.LimitUsage)
+			for _, limit := range usage.Limits {
+				named[limit.Name] = limit
+			}
+			return usage, named
+		}
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should reset the current window of the given limits", func() {
+			uid := strconv.Itoa(algorithmUID + 31)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 3, RateUnit: models.MinuteUnit, Quota: models.Quota{Number: 100, Interval: models.MonthType}})
+			expectChecks(uid, 3)
+
+			Expect(services.ResetCounters(services.DefaultDomain, uid, models.CounterReset{Audit: support, Limits: []string{"rate"}})).To(BeNil())
+			expectChecks(uid, 3)
+
+			_, named := usageOf(uid)
+			Expect(named["quota"].Used).To(Equal(6))
+
+			// Every limit by default
+			Expect(services.ResetCounters(services.DefaultDomain, uid, models.CounterReset{})).To(BeNil())
+			_, named = usageOf(uid)
+			Expect(named["rate"].Used).To(Equal(0))
+			Expect(named["quota"].Used).To(Equal(0))
+		})
+
+		It("should take the credit before the quota", func() {
+			uid := strconv.Itoa(algorithmUID + 32)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: models.Quota{Number: 2, Interval: models.MonthType}})
+
+			Expect(services.CreditCounters(services.DefaultDomain, uid, models.CounterCredit{Audit: support, Hits: 3})).To(BeNil())
+
+			status, err := services.Check(uid)
+			Expect(err).To(BeNil())
+			Expect(status).To(BeTrue())
+
+			usage, named := usageOf(uid)
+			Expect(usage.Credit).To(Equal(2))
+			Expect(named["quota"].Used).To(Equal(0))
+			Expect(named["rate"].Used).To(Equal(1))
+
+			now = algorithmStart.Add(time.Second)
+			expectChecks(uid, 4)
+
+			usage, named = usageOf(uid)
+			Expect(usage.Credit).To(Equal(0))
+			Expect(named["quota"].Used).To(Equal(2))
+		})
+
+		It("should set the counter of a limit", func() {
+			uid := strconv.Itoa(algorithmUID + 33)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 100, Quota: models.Quota{Number: 100, Interval: models.MonthType}})
+
+			Expect(services.SetCounter(services.DefaultDomain, uid, "quota", models.CounterValue{Audit: support, Used: 98})).To(BeNil())
+			expectChecks(uid, 2)
+
+			_, named := usageOf(uid)
+			Expect(named["quota"].Used).To(Equal(100))
+		})
+
+		It("should reject the invalid operations", func() {
+			uid := strconv.Itoa(algorithmUID + 34)
+			services.CreateOrUpdateConfig(uid, models.Config{Enabled: true, Rate: 10, Limits: []models.Limit{{Name: "burst", Number: 5, Algorithm: models.TokenBucketType}}})
+
+			err := services.ResetCounters(services.DefaultDomain, uid, models.CounterReset{Limits: []string{"rate", "quota"}})
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{{Field: "limits[1]", Message: `unknown limit "quota"`}}}))
+
+			err = services.CreditCounters(services.DefaultDomain, uid, models.CounterCredit{Hits: 0})
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "hits", Message: "must be positive"},
+				{Field: "hits", Message: "no quota to credit"},
+			}}))
+
+			err = services.SetCounter(services.DefaultDomain, uid, "burst", models.CounterValue{Used: 6})
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{{Field: "used", Message: "must not exceed 5"}}}))
+
+			Expect(services.SetCounter(services.DefaultDomain, uid, "quota", models.CounterValue{Used: 1})).To(Equal(services.ErrUnknownLimit))
+
+			// Without policy service to fetch it from
+			endpoint := os.Getenv("POLICY_SERVICE_ENDPOINT")
+			os.Setenv("POLICY_SERVICE_ENDPOINT", "")
+			defer os.Setenv("POLICY_SERVICE_ENDPOINT", endpoint)
+
+			Expect(services.SetCounter(services.DefaultDomain, strconv.Itoa(algorithmUID+30), "rate", models.CounterValue{Used: 1})).To(Equal(services.ErrNoConfig))
+		})
+
+		It("should record every operation, the latest first", func() {
+			uid := strconv.Itoa(algorithmUID + 31)
+
+			entries, err := services.GetAudit(services.DefaultDomain, uid)
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Action).To(Equal(models.ResetAction))
+			Expect(entries[0].Limits).To(Equal([]string{"rate", "quota"}))
+			Expect(entries[1].Audit).To(Equal(support))
+			Expect(entries[1].Limits).To(Equal([]string{"rate"}))
+			Expect(entries[1].At.Equal(algorithmStart)).To(BeTrue())
+
+			entries, err = services.GetAudit(services.DefaultDomain, strconv.Itoa(algorithmUID+32))
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Action).To(Equal(models.CreditAction))
+			Expect(entries[0].Hits).To(Equal(3))
+		})
+	})
+	Context("Listing", func() {
+		enabled, disabled := true, false
+
+		// listUIDs : Uids of every config of the listing domain matching the
+		// filter, page by page, checking the total of every page
+		listUIDs := func(filter models.ConfigFilter, total int) []string {
+			var uids []string
+			cursor := ""
+			for {
+				page, err := services.ListConfigs("listing", filter, cursor, 2, true)
+				Expect(err).To(BeNil())
+				Expect(page.Total).NotTo(BeNil())
+				Expect(*page.Total).To(Equal(total))
+
+				for _, entry := range page.Configs {
+					uids = append(uids, entry.UID)
+				}
+
+				if cursor = page.NextCursor; len(cursor) <= 0 {
+					return uids
+				}
+			}
+		}
+
+		BeforeEach(func() {
+			services.CreateOrUpdatePlan("listing-plan", models.Config{Rate: 10, Quota: models.Quota{Number: 100, Interval: models.DayType}})
+
+			monthly := models.Quota{Number: 100, Interval: models.MonthType}
+			services.CreateOrUpdateDomainConfig("listing", "1", models.Config{Enabled: true, Rate: 10, Quota: monthly})
+			services.CreateOrUpdateDomainConfig("listing", "2", models.Config{Enabled: true, Rate: 20, Quota: monthly})
+			services.CreateOrUpdateDomainConfig("listing", "3", models.Config{Enabled: false, Rate: 10, Quota: monthly})
+			services.CreateOrUpdateDomainConfig("listing", "4", models.Config{Enabled: true, Plan: "listing-plan"})
+			services.CreateOrUpdateDomainConfig("listing", "5", models.Config{Enabled: true, Limits: []models.Limit{{Number: 10, Interval: models.WeekType}}})
+		})
+
+		It("should list every config of the domain", func() {
+			Expect(listUIDs(models.ConfigFilter{}, 5)).To(ConsistOf("1", "2", "3", "4", "5"))
+		})
+
+		It("should only count the configs when asked for", func() {
+			page, err := services.ListConfigs("listing", models.ConfigFilter{}, "", 2, false)
+			Expect(err).To(BeNil())
+			Expect(page.Total).To(BeNil())
+			Expect(page.Configs).NotTo(BeEmpty())
+		})
+
+		It("should filter the configs", func() {
+			Expect(listUIDs(models.ConfigFilter{Enabled: &disabled}, 1)).To(ConsistOf("3"))
+			Expect(listUIDs(models.ConfigFilter{Plan: "listing-plan"}, 1)).To(ConsistOf("4"))
+			Expect(listUIDs(models.ConfigFilter{Interval: models.DayType}, 1)).To(ConsistOf("4"))
+			Expect(listUIDs(models.ConfigFilter{Interval: models.WeekType}, 1)).To(ConsistOf("5"))
+			Expect(listUIDs(models.ConfigFilter{Enabled: &enabled, Interval: models.MonthType}, 2)).To(ConsistOf("1", "2"))
+		})
+
+		It("should return the configs with their origin", func() {
+			page, err := services.ListConfigs("listing", models.ConfigFilter{Plan: "listing-plan"}, "", 0, false)
+			Expect(err).To(BeNil())
+			Expect(page.Configs).To(Equal([]models.ConfigEntry{{
+				UID:          "4",
+				StoredConfig: models.StoredConfig{Config: models.Config{Enabled: true, Plan: "listing-plan"}, Source: models.LocalSource},
+			}}))
+		})
+
+		It("should reject an invalid filter or cursor", func() {
+			_, err := services.ListConfigs("listing", models.ConfigFilter{Interval: "fortnight"}, "next", 0, false)
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "interval_type", Message: `unknown interval type "fortnight"`},
+				{Field: "cursor", Message: `invalid cursor "next"`},
+			}}))
+		})
+	})
+
+	Context("Bulk import and export", func() {
+		var now time.Time
+		monthly := models.Config{Enabled: true, Rate: 100, Quota: models.Quota{Number: 100, Interval: models.MonthType}}
+
+		// importedUsage : Usage of the limits of a uid of a domain, per name
+		importedUsage := func(domain string, uid string) (models.Usage, map[string]models.LimitUsage) {
+			usage, err := services.GetUsage(domain, uid)
+			Expect(err).To(BeNil())
+
+			named := make(map[string]models.LimitUsage)
+			for _, limit := range usage.Limits {
+				named[limit.Name] = limit
+			}
+			return usage, named
+		}
+
+		// exportSource : Configs of a new source domain, along with their counters
+		exportSource := func(domain string, counters bool) []models.ConfigItem {
+			services.CreateOrUpdateDomainConfig(domain, "1", monthly)
+			services.CreateOrUpdateDomainConfig(domain, "2", models.Config{Enabled: false, Rate: 10})
+			services.SetCounter(domain, "1", "quota", models.CounterValue{Used: 40})
+			services.CreditCounters(domain, "1", models.CounterCredit{Hits: 5})
+
+			items, err := services.ExportConfigs(domain, counters)
+			Expect(err).To(BeNil())
+			return items
+		}
+
+		BeforeEach(func() {
+			now = algorithmStart
+			services.SetClock(func() time.Time { return now })
+		})
+
+		AfterEach(func() {
+			services.SetClock(nil)
+		})
+
+		It("should export the configs of a domain along with their counters", func() {
+			items := exportSource("bulk-source", false)
+			Expect(items).To(HaveLen(2))
+			Expect(items[0].UID).To(Equal("1"))
+			Expect(items[0].Config).To(Equal(monthly))
+			Expect(items[0].Counters).To(BeNil())
+
+			items, err := services.ExportConfigs("bulk-source", true)
+			Expect(err).To(BeNil())
+			Expect(items[0].Counters.Credit).To(Equal(5))
+			Expect(items[0].Counters.Limits).To(HaveLen(1))
+			Expect(items[0].Counters.Limits[0].Name).To(Equal("quota"))
+			Expect(items[0].Counters.Limits[0].Used).To(Equal(40))
+			Expect(items[0].Counters.Limits[0].WindowEnd.Equal(time.Date(2030, time.February, 1, 0, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(items[1].Counters).To(BeNil())
+		})
+
+		It("should import the configs along with their counters", func() {
+			items := exportSource("bulk-copy", true)
+			services.CreateOrUpdateDomainConfig("bulk-target", "2", models.Config{Enabled: true, Rate: 1})
+
+			report, err := services.ImportConfigs("bulk-target", items)
+			Expect(err).To(BeNil())
+			Expect(report).To(Equal(models.ImportReport{Created: 1, Updated: 1, Items: []models.ImportResult{
+				{Index: 0, UID: "1", Status: models.CreatedStatus},
+				{Index: 1, UID: "2", Status: models.UpdatedStatus},
+			}}))
+
+			config, err := services.GetDomainConfig("bulk-target", "2")
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(models.Config{Enabled: false, Rate: 10}))
+
+			usage, named := importedUsage("bulk-target", "1")
+			Expect(usage.Credit).To(Equal(5))
+			Expect(named["quota"].Used).To(Equal(40))
+
+			entries, err := services.GetAudit("bulk-target", "1")
+			Expect(err).To(BeNil())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Action).To(Equal(models.ImportAction))
+			Expect(entries[0].Limits).To(Equal([]string{"quota"}))
+		})
+
+		It("should not restore the counters of an ended window", func() {
+			items := exportSource("bulk-ended", true)
+
+			now = time.Date(2030, time.February, 2, 0, 0, 0, 0, time.UTC)
+			report, err := services.ImportConfigs("bulk-expired", items[:1])
+			Expect(err).To(BeNil())
+			Expect(report.Items[0].Expired).To(Equal([]string{"quota"}))
+
+			_, named := importedUsage("bulk-expired", "1")
+			Expect(named["quota"].Used).To(Equal(0))
+		})
+
+		It("should reject every item at once, importing none", func() {
+			used := []models.LimitState{{Name: "quota", Used: 101}, {Name: "hourly", Used: 1}}
+			items := []models.ConfigItem{
+				{ConfigEntry: models.ConfigEntry{UID: "1", StoredConfig: models.StoredConfig{Config: monthly}}},
+				{ConfigEntry: models.ConfigEntry{UID: "user", StoredConfig: models.StoredConfig{Config: models.Config{Rate: -1}}}},
+				{ConfigEntry: models.ConfigEntry{UID: "1", StoredConfig: models.StoredConfig{Config: monthly}}, Counters: &models.CounterState{Limits: used}},
+			}
+
+			_, err := services.ImportConfigs("bulk-invalid", items)
+			Expect(err).To(Equal(&helper.ValidationError{Violations: []helper.Violation{
+				{Field: "[1].uid", Message: `must be an integer, got "user"`},
+				{Field: "[1].rate", Message: "must not be negative"},
+				{Field: "[2].counters.limits[0].used", Message: "must not exceed 100"},
+				{Field: "[2].counters.limits[1].name", Message: `unknown limit "hourly"`},
+				{Field: "[2].uid", Message: "duplicate of [0]"},
+			}}))
+
+			_, err = services.GetStoredConfig("bulk-invalid", "1")
+			Expect(err).NotTo(BeNil())
+		})
+	})
 })